@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/viper"
+)
+
+// auditedStatements are the statement types recorded in the audit log
+var auditedStatements = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"CREATE": true,
+	"DROP":   true,
+	"ALTER":  true,
+}
+
+// auditEntry is one line of the audit log
+type auditEntry struct {
+	Timestamp    string `json:"timestamp"`
+	User         string `json:"user,omitempty"`
+	ProjectID    string `json:"projectId"`
+	ServerID     string `json:"serverId"`
+	NameserverID string `json:"nameserverId,omitempty"`
+	Type         string `json:"type"`
+	Statement    string `json:"statement"`
+	RowsAffected int    `json:"rowsAffected"`
+}
+
+// auditEnabled reports whether the audit log is turned on with
+// "audit_log: true" in ~/.flux-relay/config.yaml
+func auditEnabled() bool {
+	return viper.GetBool("audit_log")
+}
+
+// auditMutation appends a successfully executed mutating statement to
+// ~/.flux-relay/audit.log as one JSON line. Other statements are ignored, and
+// a failure to write the log is reported as a warning rather than an error.
+func auditMutation(projectID, serverID, nameserverID, query string, queryResponse *api.QueryResponse) {
+	if !auditEnabled() {
+		return
+	}
+	statementType := leadingKeyword(query)
+	if !auditedStatements[statementType] {
+		return
+	}
+
+	cfg := config.New()
+	entry := auditEntry{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		ProjectID:    projectID,
+		ServerID:     serverID,
+		NameserverID: nameserverID,
+		Type:         statementType,
+		Statement:    query,
+		RowsAffected: queryResponse.RowsAffected,
+	}
+	if stored, err := cfg.Load(); err == nil && stored != nil {
+		entry.User = stored.Email
+	}
+
+	if err := appendJSONLine(filepath.Join(cfg.ConfigDir(), "audit.log"), entry); err != nil {
+		fmt.Fprintln(os.Stderr, ui.Warn("Could not write audit log: %v", err))
+	}
+}
+
+// appendJSONLine appends v to a JSON Lines file, creating it owner-only
+func appendJSONLine(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
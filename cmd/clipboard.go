@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands lists the programs that print the clipboard on Linux and
+// other Unix systems, tried in order. wl-paste is only used under Wayland.
+var clipboardCommands = [][]string{
+	{"wl-paste", "--no-newline"},
+	{"xclip", "-selection", "clipboard", "-out"},
+	{"xsel", "--clipboard", "--output"},
+	// WSL can read the Windows clipboard
+	{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"},
+}
+
+// readClipboard returns the text on the system clipboard
+func readClipboard() (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	default:
+		for _, command := range clipboardCommands {
+			if command[0] == "wl-paste" && os.Getenv("WAYLAND_DISPLAY") == "" {
+				continue
+			}
+			candidates = append(candidates, command)
+		}
+	}
+
+	for _, command := range candidates {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command(path, command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the clipboard with %s: %w", command[0], err)
+		}
+		// PowerShell ends lines with \r\n
+		return strings.ReplaceAll(string(out), "\r\n", "\n"), nil
+	}
+	names := make([]string, len(candidates))
+	for i, command := range candidates {
+		names[i] = command[0]
+	}
+	return "", fmt.Errorf("can't read the clipboard: none of %s is installed", strings.Join(names, ", "))
+}
+
+// clipboardQuery reads a query from the clipboard for sql --clipboard and
+// the shell's .paste
+func clipboardQuery() (string, error) {
+	text, err := readClipboard()
+	if err != nil {
+		return "", err
+	}
+	query := strings.TrimSpace(text)
+	if query == "" {
+		return "", fmt.Errorf("the clipboard is empty")
+	}
+	return query, nil
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -17,26 +21,95 @@ var configCmd = &cobra.Command{
 var configSetCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Set a configuration value",
-	Long:  "Set configuration values like token",
+	Long:  "Set configuration values like the token or the default schema type",
 }
 
 var configSetTokenCmd = &cobra.Command{
-	Use:   "token",
+	Use:   "token [token]",
 	Short: "Set the access token",
-	Long:  "Set the access token for authentication",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runConfigSetToken,
+	Long: `Set the access token for authentication.
+
+A token passed as an argument ends up in your shell history and is visible to
+other users in the process list. Use --token-stdin to read it from standard
+input instead, e.g. from a file or a CI secret.
+
+Examples:
+  flux-relay config set token --token-stdin < token.txt
+  echo "$FLUX_RELAY_TOKEN" | flux-relay config set token --token-stdin
+  flux-relay config set token "YOUR_TOKEN"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if configTokenStdin {
+			if len(args) > 0 {
+				return fmt.Errorf("don't pass a token argument with --token-stdin")
+			}
+			return nil
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("requires a token argument, or --token-stdin to read it from standard input")
+		}
+		return nil
+	},
+	RunE: runConfigSetToken,
+}
+
+var configTokenStdin bool
+
+var configSetDefaultSchemaTypeCmd = &cobra.Command{
+	Use:   "default-schema-type <messaging|analytics|both>",
+	Short: "Set the schema 'ns initialize' uses when --type is not given",
+	Long: `Save default_schema_type in the settings file (config.yaml in the config
+directory, ~/.flux-relay unless --config-dir or FLUX_RELAY_CONFIG_DIR says
+otherwise), so 'flux-relay ns initialize' uses this schema unless --type is
+passed. The file's path is printed once it is saved.
+
+Examples:
+  flux-relay config set default-schema-type both`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"messaging", "analytics", "both"},
+	RunE:      runConfigSetDefaultSchemaType,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <project|server|nameserver>",
+	Short: "Clear the selected project, server or nameserver",
+	Long: `Clear a saved selection, e.g. after the resource was deleted elsewhere.
+
+Clearing the project also clears the server and nameserver, and clearing the
+server also clears the nameserver, just like selecting a new one would.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"project", "server", "nameserver"},
+	RunE:      runConfigUnset,
+}
+
+var configResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Clear all selections",
+	Long:  "Clear the selected project, server and nameserver. You stay logged in.",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigReset,
 }
 
 func init() {
+	configSetTokenCmd.Flags().BoolVar(&configTokenStdin, "token-stdin", false, "Read the token from standard input instead of the command line")
 	configSetCmd.AddCommand(configSetTokenCmd)
+	configSetCmd.AddCommand(configSetDefaultSchemaTypeCmd)
 	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configResetCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
 func runConfigSetToken(cmd *cobra.Command, args []string) error {
-	token := args[0]
-	
+	var token string
+	if configTokenStdin {
+		var err error
+		if token, err = readTokenFromStdin(); err != nil {
+			return err
+		}
+	} else {
+		token = args[0]
+	}
+
 	// Validate token format (basic check - should be non-empty and reasonable length)
 	if len(token) == 0 {
 		return fmt.Errorf("token cannot be empty")
@@ -55,8 +128,24 @@ func runConfigSetToken(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid token: %w", err)
 	}
 
-	// Create token response
-	tokenResponse := &api.TokenResponse{
+	// Save token
+	cfg := config.New()
+	if err := cfg.SaveToken(manualTokenResponse(token, userInfo), apiURL); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	fmt.Println("Token saved successfully!")
+	fmt.Printf("   Logged in as: %s (%s)\n", userInfo.Email(), userInfo.ID())
+	fmt.Printf("   Token saved to: %s\n", cfg.ConfigPath())
+	fmt.Println()
+
+	return nil
+}
+
+// manualTokenResponse wraps a token set by hand, after it was checked with
+// GetCurrentUser, for saving like a login
+func manualTokenResponse(token string, userInfo *api.UserInfo) *api.TokenResponse {
+	return &api.TokenResponse{
 		AccessToken:  token,
 		RefreshToken: "", // Not available when setting manually
 		TokenType:    "Bearer",
@@ -69,17 +158,72 @@ func runConfigSetToken(cmd *cobra.Command, args []string) error {
 			Email: userInfo.Email(),
 		},
 	}
+}
 
-	// Save token
+// readTokenFromStdin reads a token from standard input. Surrounding
+// whitespace, including the trailing newline of echo or a file, is ignored.
+func readTokenFromStdin() (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprintln(os.Stderr, "Paste the token, then press Enter and Ctrl+D:")
+	}
+	// Read a little more than the longest accepted token so it can be rejected
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from stdin: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if strings.ContainsAny(token, " \t\r\n") {
+		return "", fmt.Errorf("expected a single token on stdin")
+	}
+	return token, nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
 	cfg := config.New()
-	if err := cfg.SaveToken(tokenResponse); err != nil {
-		return fmt.Errorf("failed to save token: %w", err)
+
+	var err error
+	var cleared string
+	switch strings.ToLower(args[0]) {
+	case "project", "pr":
+		err = cfg.ClearSelectedProject()
+		cleared = "project, server and nameserver"
+	case "server":
+		err = cfg.ClearSelectedServer()
+		cleared = "server and nameserver"
+	case "nameserver", "ns":
+		err = cfg.ClearSelectedNameserver()
+		cleared = "nameserver"
+	default:
+		return fmt.Errorf("unknown selection '%s'. Use project, server or nameserver", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clear selection: %w", err)
 	}
 
-	fmt.Println("Token saved successfully!")
-	fmt.Printf("   Logged in as: %s (%s)\n", userInfo.Email(), userInfo.ID())
-	fmt.Printf("   Token saved to: %s\n", cfg.ConfigPath())
-	fmt.Println()
+	fmt.Println(ui.Success("Cleared selected %s", cleared))
+	return nil
+}
+
+func runConfigReset(cmd *cobra.Command, args []string) error {
+	cfg := config.New()
+	if err := cfg.ClearSelections(); err != nil {
+		return fmt.Errorf("failed to clear selections: %w", err)
+	}
 
+	fmt.Println(ui.Success("Cleared selected project, server and nameserver"))
+	fmt.Println("   Your login was kept. Use 'flux-relay pr list' to select a project.")
+	return nil
+}
+
+func runConfigSetDefaultSchemaType(cmd *cobra.Command, args []string) error {
+	if !validSchemaTypes[args[0]] {
+		return fmt.Errorf("invalid schema type '%s'. Must be 'messaging', 'analytics', or 'both'", args[0])
+	}
+	path, err := saveSetting("default_schema_type", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to save setting: %w", err)
+	}
+	fmt.Println(ui.Success("Default schema type set to '%s'", args[0]))
+	fmt.Printf("   Saved to: %s\n", path)
 	return nil
 }
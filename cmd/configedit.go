@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in your editor",
+	Long: `Open the config file (config.json in the config directory, ~/.flux-relay
+unless --config-dir or FLUX_RELAY_CONFIG_DIR says otherwise) in $VISUAL or
+$EDITOR, falling back to vi (notepad on Windows).
+
+You edit a copy; it replaces the config file only once it is valid JSON with
+the known fields and sensible values. If it isn't, the problem is shown and
+you can edit it again, and a broken copy is never saved over the config.
+
+Examples:
+  flux-relay config edit
+  EDITOR="code --wait" flux-relay config edit`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+func init() {
+	configCmd.AddCommand(configEditCmd)
+}
+
+// editorCommand returns the user's editor and its arguments
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// jsonErrorPosition adds the line and column to JSON decoding errors, which
+// only report a byte offset
+func jsonErrorPosition(data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	// Nothing here is a usage mistake
+	cmd.SilenceUsage = true
+
+	cfg := config.New()
+	original, err := os.ReadFile(cfg.ConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no config file at %s yet. Run 'flux-relay login' first", cfg.ConfigPath())
+		}
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// Edit a copy next to the config, readable only by the owner like the
+	// config itself
+	copyFile, err := os.CreateTemp(cfg.ConfigDir(), "config-edit-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create a copy to edit: %w", err)
+	}
+	copyPath := copyFile.Name()
+	_, err = copyFile.Write(original)
+	if closeErr := copyFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(copyPath)
+		return fmt.Errorf("failed to create a copy to edit: %w", err)
+	}
+	keepCopy := false
+	defer func() {
+		if !keepCopy {
+			os.Remove(copyPath)
+		}
+	}()
+
+	editor := editorCommand()
+	for {
+		edit := exec.Command(editor[0], append(editor[1:], copyPath)...)
+		edit.Stdin = os.Stdin
+		edit.Stdout = os.Stdout
+		edit.Stderr = os.Stderr
+		if err := edit.Run(); err != nil {
+			return fmt.Errorf("editor %s failed: %w. The config was not changed", editor[0], err)
+		}
+
+		edited, err := os.ReadFile(copyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the edited copy: %w", err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes made.")
+			return nil
+		}
+
+		parsed, err := config.ParseConfig(edited)
+		if err != nil {
+			fmt.Println(ui.Error("The edited config is not valid: %v", jsonErrorPosition(edited, err)))
+			if askYesNo("Edit it again?") {
+				continue
+			}
+			keepCopy = true
+			return fmt.Errorf("config not changed. Your edits are in %s", copyPath)
+		}
+
+		if err := cfg.Replace(original, parsed); err != nil {
+			keepCopy = true
+			return fmt.Errorf("config not changed: %w. Your edits are in %s", err, copyPath)
+		}
+		fmt.Println(ui.Success("Saved %s", cfg.ConfigPath()))
+		return nil
+	}
+}
@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var nsCopyDataCmd = &cobra.Command{
+	Use:   "copy-data <source-nameserver> <target-nameserver>",
+	Short: "Copy rows from one nameserver's tables to another's",
+	Long: `Copy the rows of every table of the source nameserver into the target
+nameserver's table of the same base name, e.g. messages_prod into
+messages_staging. Both nameservers must be in the selected server (or
+--server), and the target tables must already exist, e.g. from
+'flux-relay ns initialize'.
+
+Rows are read and written in batches of --batch-size, in rowid order, or in
+primary key order for WITHOUT ROWID tables. Tables with a server_id column
+are copied for the current server only; other tables are copied whole.
+
+--on-conflict decides what happens to a row whose key already exists in the
+target: 'fail' (the default) stops the copy, 'skip' keeps the target's row
+(INSERT OR IGNORE) and 'replace' overwrites it (INSERT OR REPLACE).
+
+Examples:
+  flux-relay ns copy-data prod staging
+  flux-relay ns copy-data prod staging --tables conversations,messages
+  flux-relay ns copy-data prod staging --on-conflict skip`,
+	Args: cobra.ExactArgs(2),
+	RunE: runNsCopyData,
+}
+
+var (
+	copyDataTables     []string
+	copyDataOnConflict string
+	copyDataBatchSize  int
+)
+
+func init() {
+	nsCopyDataCmd.Flags().StringSliceVar(&copyDataTables, "tables", nil, "Copy only these tables, by base name (e.g. conversations,messages)")
+	nsCopyDataCmd.Flags().StringVar(&copyDataOnConflict, "on-conflict", "fail", "When a row's key already exists in the target: fail, skip or replace")
+	nsCopyDataCmd.Flags().IntVar(&copyDataBatchSize, "batch-size", 500, "Rows read per request")
+	nsCmd.AddCommand(nsCopyDataCmd)
+}
+
+// copyInsertVerbs maps --on-conflict to the INSERT form used
+var copyInsertVerbs = map[string]string{
+	"fail":    "INSERT",
+	"skip":    "INSERT OR IGNORE",
+	"replace": "INSERT OR REPLACE",
+}
+
+// maxInsertParams keeps a multi-row INSERT under SQLite's default limit of
+// 999 bound parameters
+const maxInsertParams = 999
+
+// suffixedTable is a table of a nameserver
+type suffixedTable struct {
+	name         string
+	byServerID   bool // has a server_id column
+	withoutRowID bool // declared WITHOUT ROWID
+}
+
+// copyTable is a table ns copy-data copies, and its counts once copied
+type copyTable struct {
+	base         string
+	source       string
+	target       string
+	byServerID   bool
+	withoutRowID bool
+	read         int
+	written      int
+}
+
+func runNsCopyData(cmd *cobra.Command, args []string) error {
+	verb, ok := copyInsertVerbs[copyDataOnConflict]
+	if !ok {
+		return fmt.Errorf("invalid --on-conflict '%s'. Must be 'fail', 'skip' or 'replace'", copyDataOnConflict)
+	}
+	if copyDataBatchSize < 1 {
+		return fmt.Errorf("--batch-size must be at least 1")
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+
+	source, err := resolveNameserver(client, accessToken, projectID, serverID, args[0])
+	if err != nil {
+		return err
+	}
+	target, err := resolveNameserver(client, accessToken, projectID, serverID, args[1])
+	if err != nil {
+		return err
+	}
+	if source.ID == target.ID {
+		return fmt.Errorf("source and target are the same nameserver")
+	}
+
+	// Don't show usage for missing tables or a failed copy; the error explains it
+	cmd.SilenceUsage = true
+
+	tables, err := copyDataPlan(client, accessToken, projectID, serverID, source.DatabaseName, target.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Copying %d table(s) from %s to %s...\n", len(tables), source.DatabaseName, target.DatabaseName)
+	progress := isTerminal(os.Stderr)
+	for i := range tables {
+		table := &tables[i]
+		err := copyTableRows(client, accessToken, projectID, serverID, table, verb, func() {
+			if progress {
+				fmt.Fprintf(os.Stderr, "\r  %s → %s: %d rows", table.source, table.target, table.read)
+			}
+		})
+		if progress {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return fmt.Errorf("copying %s to %s failed after %d rows: %w", table.source, table.target, table.written, err)
+		}
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tTARGET\tCOPIED\tSKIPPED")
+	fmt.Fprintln(w, "──\t──\t──\t──")
+	total := 0
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", table.source, table.target, table.written, table.read-table.written)
+		total += table.written
+	}
+	w.Flush()
+	fmt.Println()
+	fmt.Println(ui.Success("Copied %d row(s) from %s to %s", total, source.DatabaseName, target.DatabaseName))
+	return nil
+}
+
+// copyDataPlan pairs the source nameserver's tables, filtered by --tables,
+// with the target's tables of the same base name. Every target table must
+// exist before anything is copied.
+func copyDataPlan(client *api.Client, accessToken, projectID, serverID, sourceName, targetName string) ([]copyTable, error) {
+	sourceTables, err := nameserverTables(client, accessToken, projectID, serverID, sourceName)
+	if err != nil {
+		return nil, err
+	}
+	targetTables, err := nameserverTables(client, accessToken, projectID, serverID, targetName)
+	if err != nil {
+		return nil, err
+	}
+	targetExists := make(map[string]bool, len(targetTables))
+	for _, table := range targetTables {
+		targetExists[strings.ToLower(table.name)] = true
+	}
+
+	wanted := make(map[string]bool, len(copyDataTables))
+	for _, name := range copyDataTables {
+		// Accept messages as well as messages_<source>
+		name = strings.ToLower(strings.TrimSpace(name))
+		wanted[strings.TrimSuffix(name, "_"+strings.ToLower(sourceName))] = true
+	}
+
+	found := make(map[string]bool, len(wanted))
+	var tables []copyTable
+	var missing []string
+	for _, table := range sourceTables {
+		base := table.name[:len(table.name)-len(sourceName)-1]
+		if len(wanted) > 0 {
+			if !wanted[strings.ToLower(base)] {
+				continue
+			}
+			found[strings.ToLower(base)] = true
+		}
+		targetTable := base + "_" + targetName
+		if !targetExists[strings.ToLower(targetTable)] {
+			missing = append(missing, targetTable)
+			continue
+		}
+		tables = append(tables, copyTable{base: base, source: table.name, target: targetTable, byServerID: table.byServerID, withoutRowID: table.withoutRowID})
+	}
+
+	var unknown []string
+	for name := range wanted {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("nameserver '%s' has no table(s) named %s", sourceName, strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("target table(s) missing in nameserver '%s': %s\nCreate them first, e.g. with 'flux-relay ns initialize %s'",
+			targetName, strings.Join(missing, ", "), targetName)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables with suffix '_%s' to copy", sourceName)
+	}
+	return tables, nil
+}
+
+// nameserverTables lists the tables carrying a nameserver's suffix, whether
+// each has a server_id column and whether it was declared WITHOUT ROWID
+func nameserverTables(client *api.Client, accessToken, projectID, serverID, nameserverName string) ([]suffixedTable, error) {
+	query := "SELECT m.name, EXISTS (SELECT 1 FROM pragma_table_info(m.name) WHERE name = 'server_id') AS has_server_id, m.sql " +
+		"FROM sqlite_master AS m WHERE m.type = 'table' AND m." + suffixedTableCondition(nameserverName) + " ORDER BY m.name"
+	queryResponse, err := executeSqlQuery(client, accessToken, projectID, serverID, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables of nameserver '%s': %w", nameserverName, err)
+	}
+
+	tables := make([]suffixedTable, 0, len(queryResponse.Rows))
+	for _, row := range queryResponse.Rows {
+		if len(row) < 2 || row[0] == nil {
+			continue
+		}
+		table := suffixedTable{name: render.CellText(row[0]), byServerID: render.CellText(row[1]) == "1"}
+		if len(row) > 2 && row[2] != nil {
+			table.withoutRowID = declaredWithoutRowID(render.CellText(row[2]))
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// declaredWithoutRowID reports whether a CREATE TABLE statement, as kept in
+// sqlite_master, ends with the WITHOUT ROWID table option. Reading it from
+// the schema works on any SQLite version, unlike pragma_table_list.
+func declaredWithoutRowID(createSQL string) bool {
+	depth := 0
+	tokens := scanSQLTokens(createSQL)
+	for i, tok := range tokens {
+		switch {
+		case tok.text == "(":
+			depth++
+		case tok.text == ")":
+			depth--
+		case depth == 0 && tok.word && strings.EqualFold(tok.text, "WITHOUT"):
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1].text, "ROWID") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyTableRows copies a table in batches of --batch-size rows, read in rowid
+// order so each batch continues where the last one ended. progress is called
+// after every batch.
+func copyTableRows(client *api.Client, accessToken, projectID, serverID string, table *copyTable, verb string, progress func()) error {
+	if table.withoutRowID {
+		return copyTableRowsByPrimaryKey(client, accessToken, projectID, serverID, table, verb, progress)
+	}
+
+	var afterRowID *int64
+	for {
+		// executeSqlQuery binds the current server's ID to the '?'
+		var conditions []string
+		if table.byServerID {
+			conditions = append(conditions, "server_id = ?")
+		}
+		if afterRowID != nil {
+			conditions = append(conditions, fmt.Sprintf("rowid > %d", *afterRowID))
+		}
+		query := "SELECT rowid, * FROM " + quoteIdentifier(table.source)
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		query += fmt.Sprintf(" ORDER BY rowid LIMIT %d", copyDataBatchSize)
+
+		batch, err := executeSqlQuery(client, accessToken, projectID, serverID, query, nil)
+		if err != nil {
+			return err
+		}
+		if len(batch.Rows) == 0 {
+			return nil
+		}
+
+		// The first column is the rowid, the rest are the table's columns
+		columns := batch.Columns[1:]
+		rows := make([][]interface{}, 0, len(batch.Rows))
+		for _, row := range batch.Rows {
+			rowID, ok := row[0].(float64)
+			if !ok {
+				return fmt.Errorf("unexpected rowid %v", row[0])
+			}
+			id := int64(rowID)
+			afterRowID = &id
+			rows = append(rows, row[1:])
+		}
+
+		if err := insertCopiedRows(client, accessToken, projectID, serverID, table, verb, columns, rows); err != nil {
+			return err
+		}
+		progress()
+
+		if len(batch.Rows) < copyDataBatchSize {
+			return nil
+		}
+	}
+}
+
+// copyTableRowsByPrimaryKey copies a WITHOUT ROWID table, which has no rowid
+// to continue from, in batches read in primary key order, each continuing
+// after the last key of the one before
+func copyTableRowsByPrimaryKey(client *api.Client, accessToken, projectID, serverID string, table *copyTable, verb string, progress func()) error {
+	keyResponse, err := executeSqlQuery(client, accessToken, projectID, serverID,
+		"SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", []interface{}{table.source})
+	if err != nil {
+		return err
+	}
+	var keys, quotedKeys []string
+	for _, row := range keyResponse.Rows {
+		if len(row) > 0 && row[0] != nil {
+			keys = append(keys, render.CellText(row[0]))
+			quotedKeys = append(quotedKeys, quoteIdentifier(render.CellText(row[0])))
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("table '%s' has neither a rowid nor a primary key to copy it by", table.source)
+	}
+	keyList := "(" + strings.Join(quotedKeys, ", ") + ")"
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ") + ")"
+
+	var afterKey []interface{}
+	for {
+		// The arguments are bound explicitly, so server_id's too
+		var conditions []string
+		queryArgs := []interface{}{}
+		if table.byServerID {
+			conditions = append(conditions, "server_id = ?")
+			queryArgs = append(queryArgs, serverID)
+		}
+		if afterKey != nil {
+			conditions = append(conditions, keyList+" > "+placeholders)
+			queryArgs = append(queryArgs, afterKey...)
+		}
+		query := "SELECT * FROM " + quoteIdentifier(table.source)
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		query += fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(quotedKeys, ", "), copyDataBatchSize)
+
+		batch, err := executeSqlQuery(client, accessToken, projectID, serverID, query, queryArgs)
+		if err != nil {
+			return err
+		}
+		if len(batch.Rows) == 0 {
+			return nil
+		}
+
+		afterKey, err = rowKey(batch.Columns, batch.Rows[len(batch.Rows)-1], keys)
+		if err != nil {
+			return fmt.Errorf("table '%s': %w", table.source, err)
+		}
+		if err := insertCopiedRows(client, accessToken, projectID, serverID, table, verb, batch.Columns, batch.Rows); err != nil {
+			return err
+		}
+		progress()
+
+		if len(batch.Rows) < copyDataBatchSize {
+			return nil
+		}
+	}
+}
+
+// rowKey returns the values of a row's key columns, to continue reading after
+// it. Whole numbers are bound as integers, since the API decodes every number
+// as a float.
+func rowKey(columns []string, row []interface{}, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		found := false
+		for j, col := range columns {
+			if col == key && j < len(row) {
+				values[i] = row[j]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("primary key column '%s' missing from the result", key)
+		}
+		if f, ok := values[i].(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			values[i] = int64(f)
+		}
+	}
+	return values, nil
+}
+
+// insertCopiedRows writes a batch of rows read from the source table into the
+// target table, in as few INSERTs as the parameter limit allows
+func insertCopiedRows(client *api.Client, accessToken, projectID, serverID string, table *copyTable, verb string, columns []string, rows [][]interface{}) error {
+	perInsert := max(1, maxInsertParams/max(1, len(columns)))
+	for start := 0; start < len(rows); start += perInsert {
+		end := min(start+perInsert, len(rows))
+		insert, insertArgs := copyInsertStatement(verb, table.target, columns, rows[start:end])
+		result, err := withLockRetry(insert, func() (*api.QueryResponse, error) {
+			return executeSqlQuery(client, accessToken, projectID, serverID, insert, insertArgs)
+		})
+		if err != nil {
+			return err
+		}
+		table.written += result.RowsAffected
+	}
+	table.read += len(rows)
+	return nil
+}
+
+// copyInsertStatement builds one multi-row INSERT for rows. Nested JSON
+// values the API decoded are written back as JSON text.
+func copyInsertStatement(verb, table string, columns []string, rows [][]interface{}) (string, []interface{}) {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+
+	values := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		values[i] = placeholders
+		for _, val := range row {
+			switch val.(type) {
+			case map[string]interface{}, []interface{}:
+				val = render.CellText(val)
+			}
+			args = append(args, val)
+		}
+	}
+	return fmt.Sprintf("%s INTO %s (%s) VALUES %s", verb, quoteIdentifier(table), strings.Join(quoted, ", "), strings.Join(values, ", ")), args
+}
+
+// quoteIdentifier quotes a table or column name for SQL
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeclaredWithoutRowID(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"CREATE TABLE a_db (id INTEGER PRIMARY KEY)", false},
+		{"CREATE TABLE a_db (k TEXT PRIMARY KEY) WITHOUT ROWID", true},
+		{"CREATE TABLE a_db (k TEXT, j INT, PRIMARY KEY (j, k))\n  without\n  rowid", true},
+		{"CREATE TABLE a_db (k TEXT PRIMARY KEY) STRICT, WITHOUT ROWID", true},
+		{"CREATE TABLE a_db (note TEXT DEFAULT 'WITHOUT ROWID')", false},
+		{`CREATE TABLE a_db ("without" TEXT, rowid_copy INT)`, false},
+	}
+	for _, tt := range tests {
+		if got := declaredWithoutRowID(tt.sql); got != tt.want {
+			t.Errorf("declaredWithoutRowID(%q) = %t, want %t", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestRowKey(t *testing.T) {
+	columns := []string{"k", "j", "note"}
+	row := []interface{}{"a", float64(12), "x"}
+	got, err := rowKey(columns, row, []string{"j", "k"})
+	if err != nil {
+		t.Fatalf("rowKey: %v", err)
+	}
+	if want := []interface{}{int64(12), "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rowKey() = %#v, want %#v", got, want)
+	}
+
+	if _, err := rowKey(columns, row, []string{"missing"}); err == nil {
+		t.Error("rowKey with a missing key column: no error")
+	}
+}
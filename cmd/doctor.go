@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common setup problems",
+	Long: `Check the CLI's environment and report what is wrong and how to fix it.
+
+Checks that the config files can be read, a login token is present and
+unexpired, the API URL is valid and reachable, the selected project, server
+and nameserver still exist, and that this binary is the 'flux-relay' found
+on PATH. Paste the output into bug reports.
+
+Exits with a non-zero status if any check fails.
+
+Examples:
+  flux-relay doctor
+  flux-relay doctor -o json`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	setOutputFormats(doctorCmd, render.FormatJSON, render.FormatYAML)
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// Doctor check results
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is one line of the doctor report
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// doctorReport is the --output json form of the doctor report
+type doctorReport struct {
+	Version  string        `json:"version"`
+	Commit   string        `json:"commit"`
+	Platform string        `json:"platform"`
+	APIURL   string        `json:"apiUrl"`
+	Checks   []doctorCheck `json:"checks"`
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+
+	report := doctorReport{
+		Version:  version,
+		Commit:   commit,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		APIURL:   getAPIURL(),
+	}
+	add := func(name, status, detail, hint string) {
+		report.Checks = append(report.Checks, doctorCheck{Name: name, Status: status, Detail: detail, Hint: hint})
+	}
+
+	// Config files
+	cfg := config.New()
+	stored, err := cfg.Load()
+	switch {
+	case err != nil:
+		add("Config file", checkFail, fmt.Sprintf("%s could not be read: %v", cfg.ConfigPath(), err),
+			"Delete the file and run 'flux-relay login' again")
+	case stored == nil:
+		add("Config file", checkWarn, fmt.Sprintf("%s does not exist", cfg.ConfigPath()), "Run 'flux-relay login'")
+	default:
+		add("Config file", checkOK, cfg.ConfigPath(), "")
+	}
+	if settingsFile := viper.ConfigFileUsed(); settingsFile != "" {
+		if err := viper.ReadInConfig(); err != nil {
+			add("Settings file", checkFail, fmt.Sprintf("%s could not be parsed: %v", settingsFile, err), "Fix the YAML syntax in the file")
+		} else {
+			add("Settings file", checkOK, settingsFile, "")
+		}
+	}
+
+	// Token, as stored
+	accessToken := ""
+	if stored != nil {
+		accessToken = stored.AccessToken
+	}
+	tokenUsable := false
+	switch {
+	case accessToken == "":
+		add("Login", checkFail, "not logged in", "Run 'flux-relay login'")
+	case time.Now().After(stored.ExpiresAt):
+		add("Login", checkFail, fmt.Sprintf("token expired at %s", stored.ExpiresAt.Local().Format("2006-01-02 15:04:05")),
+			"Run 'flux-relay login' again")
+	default:
+		tokenUsable = true
+	}
+
+	// API URL and reachability. The hints name the settings file in use, which
+	// --config-dir or FLUX_RELAY_CONFIG_DIR may have moved.
+	settingsFile, _ := settingsPath()
+	authenticated := false
+	client := api.NewClient(report.APIURL)
+	client.HTTPClient.Timeout = 10 * time.Second
+	if _, err := api.NormalizeBaseURL(report.APIURL); err != nil {
+		add("API URL", checkFail, err.Error(), "Fix --api-url or api_url in "+settingsFile)
+	} else {
+		start := time.Now()
+		userInfo, reqErr := client.GetCurrentUser(accessToken)
+		latency := time.Since(start).Milliseconds()
+
+		var apiErr *api.APIError
+		switch {
+		case reqErr == nil:
+			add("API", checkOK, fmt.Sprintf("%s reachable (%dms)", report.APIURL, latency), "")
+			authenticated = true
+			if tokenUsable {
+				add("Login", checkOK, fmt.Sprintf("%s, token valid until %s", userInfo.Email(), stored.ExpiresAt.Local().Format("2006-01-02 15:04:05")), "")
+			}
+		case errors.As(reqErr, &apiErr):
+			add("API", checkOK, fmt.Sprintf("%s reachable (%dms)", report.APIURL, latency), "")
+			if tokenUsable {
+				add("Login", checkFail, fmt.Sprintf("token rejected by the API: %v", reqErr), "Run 'flux-relay login' again")
+			}
+		default:
+			add("API", checkFail, fmt.Sprintf("%s unreachable: %v", report.APIURL, reqErr),
+				"Check your network connection, --api-url / api_url and any proxy or ca_cert in "+settingsFile)
+		}
+	}
+
+	// Saved selections
+	if authenticated {
+		doctorSelections(client, accessToken, stored, add)
+	}
+
+	// Install location
+	doctorPath(add)
+
+	// Don't show usage for a failed check; the report already explains it
+	cmd.SilenceUsage = true
+
+	failed := 0
+	for _, check := range report.Checks {
+		if check.Status == checkFail {
+			failed++
+		}
+	}
+
+	if structuredOutput() {
+		if err := printStructured(os.Stdout, report, "result"); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("flux-relay %s (%s) on %s\n\n", report.Version, report.Commit, report.Platform)
+		for _, check := range report.Checks {
+			line := ui.Success
+			switch check.Status {
+			case checkWarn:
+				line = ui.Warn
+			case checkFail:
+				line = ui.Error
+			}
+			fmt.Println(line("%s: %s", check.Name, check.Detail))
+			if check.Hint != "" {
+				fmt.Println("   " + ui.Hint("%s", check.Hint))
+			}
+		}
+		fmt.Println()
+		if failed == 0 {
+			fmt.Println("No problems found.")
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// doctorSelections checks that the saved project, server and nameserver
+// still exist
+func doctorSelections(client *api.Client, accessToken string, stored *config.Config, add func(name, status, detail, hint string)) {
+	if stored.SelectedProject == "" {
+		add("Project", checkWarn, "none selected", "Run 'flux-relay pr <project-name-or-id>'")
+		return
+	}
+	project, err := resolveProject(client, accessToken, stored.SelectedProject)
+	if err != nil {
+		add("Project", checkFail, fmt.Sprintf("selected project %s: %v", stored.SelectedProject, err), "Run 'flux-relay pr <project-name-or-id>'")
+		return
+	}
+	add("Project", checkOK, fmt.Sprintf("%s (%s)", project.Name, project.ID), "")
+
+	if stored.SelectedServer == "" {
+		add("Server", checkWarn, "none selected", "Run 'flux-relay server <server-name-or-id>'")
+		return
+	}
+	server, err := resolveServer(client, accessToken, project.ID, stored.SelectedServer)
+	if err != nil {
+		add("Server", checkFail, fmt.Sprintf("selected server %s: %v", stored.SelectedServer, err), "Run 'flux-relay server <server-name-or-id>'")
+		return
+	}
+	add("Server", checkOK, fmt.Sprintf("%s (%s)", server.Name, server.ID), "")
+
+	if stored.SelectedNameserver == "" {
+		// Optional: queries then span the whole server
+		add("Nameserver", checkOK, "none selected", "")
+		return
+	}
+	nameserver, err := resolveNameserver(client, accessToken, project.ID, server.ID, stored.SelectedNameserver)
+	if err != nil {
+		add("Nameserver", checkFail, fmt.Sprintf("selected nameserver %s: %v", stored.SelectedNameserver, err), "Run 'flux-relay ns use <nameserver-name>'")
+		return
+	}
+	add("Nameserver", checkOK, fmt.Sprintf("%s (%s)", nameserver.DatabaseName, nameserver.ID), "")
+}
+
+// doctorPath checks that the 'flux-relay' found on PATH is this binary
+func doctorPath(add func(name, status, detail, hint string)) {
+	self, err := os.Executable()
+	if err != nil {
+		add("PATH", checkWarn, fmt.Sprintf("could not determine this binary's location: %v", err), "")
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+
+	onPath, err := exec.LookPath("flux-relay")
+	if err != nil {
+		add("PATH", checkWarn, fmt.Sprintf("'flux-relay' is not on PATH (this binary is %s)", self),
+			fmt.Sprintf("Add it to your PATH: export PATH=\"$PATH:%s\"", filepath.Dir(self)))
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(onPath); err == nil {
+		onPath = resolved
+	}
+	if onPath != self {
+		add("PATH", checkWarn, fmt.Sprintf("'flux-relay' on PATH is %s, not this binary (%s)", onPath, self),
+			"Remove the older copy or reorder your PATH")
+		return
+	}
+	add("PATH", checkOK, self, "")
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+)
+
+var sqlDryRun bool
+
+// dryRunStatement is the check of one statement by --dry-run
+type dryRunStatement struct {
+	Statement string `json:"statement"`
+	Valid     bool   `json:"valid"`
+	// Unchecked is set for statements that use a table created earlier in
+	// the script, which doesn't exist yet since nothing is run
+	Unchecked bool   `json:"unchecked,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Warning is set for an UPDATE or DELETE without server_id, which the
+	// real run refuses unless --force is passed
+	Warning string `json:"warning,omitempty"`
+}
+
+// validateDryRun rejects flags --dry-run can't be combined with
+func validateDryRun(query string) error {
+	switch {
+	case sqlWatch > 0:
+		return fmt.Errorf("--dry-run cannot be used with --watch")
+	case sqlExplain, sqlCountOnly:
+		return fmt.Errorf("--dry-run cannot be used with --explain or --count-only")
+	case sqlFailOnEmpty, sqlFailOnRows:
+		return fmt.Errorf("--dry-run cannot be used with --fail-on-empty or --fail-on-rows")
+	case (sqlParamsFile != "" || len(sqlSets) > 0) && len(splitSQLStatements(query)) > 1:
+		return fmt.Errorf("--params and --set can only be used with --dry-run for a single statement")
+	}
+	return nil
+}
+
+// dryRunQuery returns the statement SQLite compiles, without running it, to
+// check stmt. An EXPLAIN statement is already never run.
+func dryRunQuery(stmt string) string {
+	if leadingKeyword(stmt) == "EXPLAIN" {
+		return stmt
+	}
+	return "EXPLAIN " + stmt
+}
+
+// createdTable returns the table a CREATE TABLE statement creates, in lower
+// case and without quotes, or "" for other statements
+func createdTable(stmt string) string {
+	tokens := scanSQLTokens(stmt)
+	i := 0
+	next := func(words ...string) bool {
+		for _, word := range words {
+			if i < len(tokens) && strings.EqualFold(tokens[i].text, word) {
+				i++
+				return true
+			}
+		}
+		return false
+	}
+	if !next("CREATE") {
+		return ""
+	}
+	next("TEMP", "TEMPORARY")
+	if !next("TABLE") {
+		return ""
+	}
+	if next("IF") && !(next("NOT") && next("EXISTS")) {
+		return ""
+	}
+	if i >= len(tokens) {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(tokens[i].text, "\"`[]"))
+}
+
+// runSqlDryRun checks every statement of query by having SQLite compile it
+// with EXPLAIN, which parses and plans a statement but never runs it. Nothing
+// is written to the history or the audit log.
+func runSqlDryRun(out io.Writer, client *api.Client, accessToken, projectID, serverID, query string, queryArgs []interface{}) error {
+	statements := splitSQLStatements(query)
+	if len(statements) == 0 {
+		return fmt.Errorf("no statements to check")
+	}
+
+	created := map[string]bool{}
+	results := make([]dryRunStatement, len(statements))
+	failed := 0
+	for i, stmt := range statements {
+		result := dryRunStatement{Statement: stmt, Valid: true}
+		_, err := executeSqlQuery(client, accessToken, projectID, serverID, dryRunQuery(stmt), queryArgs)
+		if err == errSessionExpired {
+			return err
+		}
+		if err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+			for table := range created {
+				if strings.Contains(strings.ToLower(result.Error), "no such table: "+table) {
+					result.Valid = true
+					result.Unchecked = true
+					break
+				}
+			}
+			if !result.Valid {
+				failed++
+			}
+		}
+		if table := createdTable(stmt); table != "" {
+			created[table] = true
+		}
+		if keyword := unscopedMutation(stmt); keyword != "" && !sqlForce {
+			result.Warning = fmt.Sprintf("%s without server_id in its WHERE clause; running it needs --force", keyword)
+		}
+		results[i] = result
+	}
+
+	if structuredOutput() {
+		if err := printStructured(out, results, "result"); err != nil {
+			return err
+		}
+	} else {
+		for i, result := range results {
+			switch {
+			case result.Unchecked:
+				fmt.Fprintln(out, ui.Warn("Statement %d/%d: %s", i+1, len(results), firstLine(result.Statement)))
+				fmt.Fprintln(out, "   not checked: it uses a table created earlier in the script")
+			case result.Valid && result.Warning != "":
+				fmt.Fprintln(out, ui.Warn("Statement %d/%d: %s", i+1, len(results), firstLine(result.Statement)))
+				fmt.Fprintf(out, "   %s\n", result.Warning)
+			case result.Valid:
+				fmt.Fprintln(out, ui.Success("Statement %d/%d: %s", i+1, len(results), firstLine(result.Statement)))
+			default:
+				fmt.Fprintln(out, ui.Error("Statement %d/%d: %s", i+1, len(results), firstLine(result.Statement)))
+				fmt.Fprintf(out, "   %s\n", result.Error)
+				if sqlExplainErrors {
+					for _, line := range queryErrorHint(result.Error, false) {
+						fmt.Fprintf(out, "   %s\n", line)
+					}
+				}
+			}
+		}
+		if failed == 0 {
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "No errors found in %d statement(s). Nothing was run.\n", len(results))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d statement(s) failed to parse or plan. Nothing was run", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var configImportDSNCmd = &cobra.Command{
+	Use:   "import-dsn [connection-string]",
+	Short: "Log in and select a project, server and nameserver from a connection string",
+	Long: `Set up the CLI in one step from a connection string, for Dockerfiles and
+provisioning scripts:
+
+  flux-relay://TOKEN@HOST[/PATH]?project=...&server=...&nameserver=...
+
+The token is checked with the API and saved like 'config set token', the API
+URL is saved for later commands, and the project, server and nameserver (names
+or IDs, all optional) are selected. Use flux-relay+http:// for an API without
+TLS, such as a local development server. Running it again with the same
+connection string gives the same configuration.
+
+Without an argument the connection string is read from FLUX_RELAY_DSN, which
+keeps the token out of your shell history and the process list.
+
+Examples:
+  FLUX_RELAY_DSN="flux-relay://$TOKEN@flux.postacksolutions.com?project=Chat&server=Main" flux-relay config import-dsn
+  flux-relay config import-dsn "flux-relay+http://$TOKEN@localhost:3000?project=Chat"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigImportDSN,
+}
+
+func init() {
+	configCmd.AddCommand(configImportDSNCmd)
+}
+
+// connectionString is the parsed form of a flux-relay:// connection string
+type connectionString struct {
+	APIURL     string
+	Token      string
+	Project    string
+	Server     string
+	Nameserver string
+}
+
+// parseDSN parses a connection string. Errors never include the string
+// itself, since it contains the token.
+func parseDSN(dsn string) (*connectionString, error) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: expected flux-relay://TOKEN@HOST?project=...")
+	}
+
+	var scheme string
+	switch u.Scheme {
+	case "flux-relay":
+		scheme = "https"
+	case "flux-relay+http":
+		scheme = "http"
+	default:
+		return nil, fmt.Errorf("invalid connection string: scheme must be flux-relay:// or flux-relay+http://")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid connection string: missing token (flux-relay://TOKEN@HOST)")
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return nil, fmt.Errorf("invalid connection string: the token must not contain ':' (URL-encode it as %%3A)")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid connection string: missing host")
+	}
+
+	apiURL, err := api.NormalizeBaseURL(scheme + "://" + u.Host + u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: %w", err)
+	}
+	parsed := &connectionString{APIURL: apiURL, Token: u.User.Username()}
+
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "project":
+			parsed.Project = value
+		case "server":
+			parsed.Server = value
+		case "nameserver", "ns":
+			parsed.Nameserver = value
+		default:
+			return nil, fmt.Errorf("invalid connection string: unknown parameter '%s' (use project, server or nameserver)", key)
+		}
+	}
+	if parsed.Server != "" && parsed.Project == "" {
+		return nil, fmt.Errorf("invalid connection string: server requires project")
+	}
+	if parsed.Nameserver != "" && parsed.Server == "" {
+		return nil, fmt.Errorf("invalid connection string: nameserver requires server")
+	}
+	return parsed, nil
+}
+
+func runConfigImportDSN(cmd *cobra.Command, args []string) error {
+	dsn := os.Getenv("FLUX_RELAY_DSN")
+	if len(args) == 1 {
+		dsn = args[0]
+	}
+	if dsn == "" {
+		return fmt.Errorf("no connection string given. Pass one as an argument or set FLUX_RELAY_DSN")
+	}
+
+	parsed, err := parseDSN(dsn)
+	if err != nil {
+		return err
+	}
+
+	// Validate token by getting user info
+	client := api.NewClient(parsed.APIURL)
+	userInfo, err := client.GetCurrentUser(parsed.Token)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+
+	// Resolve the selections before saving anything, so a typo leaves the
+	// config as it was
+	var project *api.Project
+	var server *api.Server
+	var nameserver *api.Database
+	if parsed.Project != "" {
+		if project, err = resolveProject(client, parsed.Token, parsed.Project); err != nil {
+			return err
+		}
+	}
+	if parsed.Server != "" {
+		if server, err = resolveServer(client, parsed.Token, project.ID, parsed.Server); err != nil {
+			return err
+		}
+	}
+	if parsed.Nameserver != "" {
+		if nameserver, err = resolveNameserver(client, parsed.Token, project.ID, server.ID, parsed.Nameserver); err != nil {
+			return err
+		}
+	}
+
+	cfg := config.New()
+	if err := cfg.SaveToken(manualTokenResponse(parsed.Token, userInfo), parsed.APIURL); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	if err := cfg.ClearSelections(); err != nil {
+		return fmt.Errorf("failed to clear selections: %w", err)
+	}
+	if project != nil {
+		if err := cfg.SetSelectedProject(project.ID); err != nil {
+			return fmt.Errorf("failed to save project selection: %w", err)
+		}
+	}
+	if server != nil {
+		if err := cfg.SetSelectedServer(server.ID); err != nil {
+			return fmt.Errorf("failed to save server selection: %w", err)
+		}
+	}
+	if nameserver != nil {
+		if err := cfg.SetSelectedNameserver(nameserver.ID); err != nil {
+			return fmt.Errorf("failed to save nameserver selection: %w", err)
+		}
+	}
+
+	fmt.Println(ui.Success("Configuration imported"))
+	fmt.Printf("   Logged in as: %s (%s)\n", userInfo.Email(), userInfo.ID())
+	fmt.Printf("   API URL: %s\n", parsed.APIURL)
+	if project != nil {
+		fmt.Printf("   Project: %s (%s)\n", project.Name, project.ID)
+	}
+	if server != nil {
+		fmt.Printf("   Server: %s (%s)\n", server.Name, server.ID)
+	}
+	if nameserver != nil {
+		fmt.Printf("   Nameserver: %s (%s)\n", nameserver.DatabaseName, nameserver.ID)
+	}
+	fmt.Printf("   Saved to: %s\n", cfg.ConfigPath())
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlForce runs UPDATE and DELETE statements that don't filter on server_id
+var sqlForce bool
+
+// unscopedMutation returns "UPDATE" or "DELETE" when stmt is such a statement
+// and its WHERE clause never mentions server_id, so it could change rows of
+// other servers, or every row in the table. Other statements return "", as do
+// changes to SQLite's own tables (e.g. sqlite_sequence), which have no
+// server_id. Statements that only read, like PRAGMA or a SELECT from
+// sqlite_master, are never reported.
+func unscopedMutation(stmt string) string {
+	tokens := scanSQLTokens(stmt)
+	keyword := ""
+	depth := 0
+	inWhere := false
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+
+		if keyword == "" {
+			if depth > 0 || !tok.word {
+				continue
+			}
+			switch upper := strings.ToUpper(tok.text); {
+			case upper == "UPDATE" || upper == "DELETE":
+				if isSystemTable(mutationTarget(tokens[i+1:])) {
+					return ""
+				}
+				keyword = upper
+			case upper == "SELECT" || upper == "INSERT" || upper == "REPLACE":
+				return ""
+			case i == 0 && upper != "WITH":
+				// Not a statement that changes rows
+				return ""
+			}
+			continue
+		}
+
+		if depth == 0 && tok.word {
+			switch strings.ToUpper(tok.text) {
+			case "WHERE":
+				inWhere = true
+				continue
+			case "RETURNING", "ORDER", "LIMIT":
+				inWhere = false
+			}
+		}
+		// Subqueries in the WHERE clause count too
+		if inWhere && isServerIDColumn(tok) {
+			return ""
+		}
+	}
+	return keyword
+}
+
+// mutationTarget returns the table named by the tokens after UPDATE or
+// DELETE, without quotes or schema
+func mutationTarget(tokens []sqlToken) string {
+	for i, tok := range tokens {
+		if tok.word {
+			switch strings.ToUpper(tok.text) {
+			case "OR", "ROLLBACK", "ABORT", "REPLACE", "FAIL", "IGNORE", "FROM":
+				continue
+			}
+		}
+		// schema.table
+		if i+2 < len(tokens) && tokens[i+1].text == "." {
+			tok = tokens[i+2]
+		}
+		return strings.Trim(tok.text, "\"`[]")
+	}
+	return ""
+}
+
+// isSystemTable reports whether table is one of SQLite's own tables, such as
+// sqlite_master or sqlite_sequence
+func isSystemTable(table string) bool {
+	return strings.HasPrefix(strings.ToLower(table), "sqlite_")
+}
+
+// isServerIDColumn reports whether tok names the server_id column, bare or
+// quoted as an identifier
+func isServerIDColumn(tok sqlToken) bool {
+	if strings.HasPrefix(tok.text, "'") {
+		return false
+	}
+	return strings.EqualFold(strings.Trim(tok.text, "\"`[]"), "server_id")
+}
+
+// unscopedMutationError explains why a statement was not run
+func unscopedMutationError(keyword string) error {
+	return fmt.Errorf("refusing to run %s without server_id in its WHERE clause: it could change other servers' rows, or every row in the table. Add \"WHERE server_id = ?\", or pass --force if that is intended", keyword)
+}
+
+// checkServerScope returns an error for the first statement in script that
+// is an UPDATE or DELETE without a server_id condition
+func checkServerScope(script string) error {
+	for _, stmt := range splitSQLStatements(script) {
+		if keyword := unscopedMutation(stmt); keyword != "" {
+			return unscopedMutationError(keyword)
+		}
+	}
+	return nil
+}
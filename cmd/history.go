@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var sqlHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List queries run with 'flux-relay sql' and the shell",
+	Long: `List past queries recorded in query_history.jsonl in the config directory
+(~/.flux-relay, or --config-dir).
+
+Every query run with 'flux-relay sql' or in the interactive shell is recorded
+with its time, target nameserver, duration and row count. Pass --no-history to
+'flux-relay sql' to skip recording one query, or set "query_history: false" in
+the settings file to turn recording off.
+
+Examples:
+  flux-relay sql history
+  flux-relay sql history --last 50
+  flux-relay sql history --grep messages_db
+  flux-relay sql history -o json --grep "COUNT(*)"`,
+	Args: cobra.NoArgs,
+	RunE: runSqlHistory,
+}
+
+var sqlNoHistory bool
+var historyGrep string
+var historyLast int
+
+func init() {
+	sqlCmd.Flags().BoolVar(&sqlNoHistory, "no-history", false, "Don't record this query in the query history")
+	sqlHistoryCmd.Flags().StringVar(&historyGrep, "grep", "", "Only show queries containing this text (case-insensitive)")
+	sqlHistoryCmd.Flags().IntVar(&historyLast, "last", 20, "Show the last N matching queries (0 for all)")
+	setOutputFormats(sqlHistoryCmd, render.FormatJSON, render.FormatYAML, render.FormatCSV)
+	sqlCmd.AddCommand(sqlHistoryCmd)
+}
+
+// queryHistoryEntry is one line of the query history
+type queryHistoryEntry struct {
+	Timestamp    string `json:"timestamp"`
+	Source       string `json:"source"`
+	ProjectID    string `json:"projectId"`
+	ServerID     string `json:"serverId"`
+	NameserverID string `json:"nameserverId,omitempty"`
+	Query        string `json:"query"`
+	DurationMs   int64  `json:"durationMs"`
+	Rows         int    `json:"rows"`
+	Error        string `json:"error,omitempty"`
+}
+
+// queryHistoryPath returns the location of the query history file
+func queryHistoryPath() string {
+	return filepath.Join(config.New().ConfigDir(), "query_history.jsonl")
+}
+
+// historyEnabled reports whether queries are recorded. It is on unless
+// "query_history: false" is set in ~/.flux-relay/config.yaml.
+func historyEnabled() bool {
+	return !viper.IsSet("query_history") || viper.GetBool("query_history")
+}
+
+// recordQuery appends a query to the history. source is "sql" or "shell".
+// Rows counts the rows returned, or the rows affected by a statement that
+// returns none. A failure to write the history is reported as a warning.
+func recordQuery(source, projectID, serverID, nameserverID, query string, started time.Time, queryResponse *api.QueryResponse, queryErr error) {
+	if !historyEnabled() {
+		return
+	}
+
+	entry := queryHistoryEntry{
+		Timestamp:    started.UTC().Format(time.RFC3339),
+		Source:       source,
+		ProjectID:    projectID,
+		ServerID:     serverID,
+		NameserverID: nameserverID,
+		Query:        query,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+	switch {
+	case queryErr != nil:
+		entry.Error = queryErr.Error()
+	case queryResponse == nil:
+	case !queryResponse.Success:
+		entry.Error = queryResponse.ErrorMessage
+		if entry.Error == "" {
+			entry.Error = "query failed"
+		}
+	case resultKind(query).ReturnsRows(queryResponse):
+		entry.Rows = len(queryResponse.Rows)
+	default:
+		entry.Rows = queryResponse.RowsAffected
+	}
+
+	if err := appendJSONLine(queryHistoryPath(), entry); err != nil {
+		fmt.Fprintln(os.Stderr, ui.Warn("Could not write query history: %v", err))
+	}
+}
+
+// readQueryHistory returns the recorded queries, oldest first. Lines that
+// can't be parsed are skipped.
+func readQueryHistory(path string) ([]queryHistoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []queryHistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry queryHistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func runSqlHistory(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	if historyLast < 0 {
+		return fmt.Errorf("--last must be 0 or more")
+	}
+
+	path := queryHistoryPath()
+	entries, err := readQueryHistory(path)
+	if err != nil {
+		return fmt.Errorf("failed to read query history: %w", err)
+	}
+
+	if historyGrep != "" {
+		needle := strings.ToLower(historyGrep)
+		matched := entries[:0]
+		for _, entry := range entries {
+			if strings.Contains(strings.ToLower(entry.Query), needle) {
+				matched = append(matched, entry)
+			}
+		}
+		entries = matched
+	}
+	if historyLast > 0 && len(entries) > historyLast {
+		entries = entries[len(entries)-historyLast:]
+	}
+
+	switch outputFormat {
+	case "json", "yaml":
+		if entries == nil {
+			entries = []queryHistoryEntry{}
+		}
+		return printStructured(os.Stdout, entries, "history")
+	case "csv":
+		fmt.Println("timestamp,source,nameserverId,durationMs,rows,error,query")
+		for _, entry := range entries {
+			fmt.Printf("%s,%s,%s,%d,%d,%s,%s\n", entry.Timestamp, entry.Source, render.CSVField(entry.NameserverID, nullString),
+				entry.DurationMs, entry.Rows, render.CSVField(entry.Error, nullString), render.CSVField(entry.Query, nullString))
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if historyGrep != "" {
+			fmt.Printf("No queries in the history match '%s'.\n", historyGrep)
+		} else {
+			fmt.Println("No queries recorded yet.")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TIME\tNAMESERVER\tDURATION\tROWS\tQUERY")
+	fmt.Fprintln(w, "──\t──\t──\t──\t──")
+	for _, entry := range entries {
+		when := entry.Timestamp
+		if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+			when = t.Local().Format("2006-01-02 15:04:05")
+		}
+		rows := fmt.Sprintf("%d", entry.Rows)
+		if entry.Error != "" {
+			rows = "error"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%dms\t%s\t%s\n", when, valueOrDash(entry.NameserverID), entry.DurationMs, rows, historyQueryText(entry.Query))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// historyQueryText fits a query on one line of the history table
+func historyQueryText(query string) string {
+	text := []rune(strings.Join(strings.Fields(query), " "))
+	if len(text) > 80 {
+		return string(text[:77]) + "..."
+	}
+	return string(text)
+}
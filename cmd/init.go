@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up the CLI: log in and select a project, server and nameserver",
+	Long: `Walk through first-time setup in one go: log in if needed, then pick a
+project, a server and a nameserver, saving each selection as 'pr', 'server'
+and 'ns use' would. A step with only one choice is picked without asking, and
+pressing Enter keeps the current selection.
+
+If the server has no nameservers, init offers to create one and initialize
+its schema (default_schema_type from the settings, or messaging).
+
+With --non-interactive (or when stdin is not a terminal) init never prompts:
+it keeps valid selections and picks single choices, and fails with the
+command to run wherever it would have asked.
+
+Examples:
+  flux-relay init
+  flux-relay init --non-interactive`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+var initNonInteractive bool
+
+func init() {
+	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "Fail instead of prompting when a choice is needed")
+	rootCmd.AddCommand(initCmd)
+}
+
+// initChoice is one option of a step of the init wizard
+type initChoice struct {
+	id    string
+	label string
+}
+
+// initStep describes a step of the init wizard for its prompts and errors
+type initStep struct {
+	kind    string // e.g. "project"
+	command string // selects one by hand, e.g. "flux-relay pr <project-name-or-id>"
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+		return err
+	}
+	interactive := !initNonInteractive && isTerminal(os.Stdin)
+	apiURL := getAPIURL()
+	cfg := config.New()
+	client := api.NewClient(apiURL)
+
+	// Don't show usage once the wizard is under way; the errors explain themselves
+	cmd.SilenceUsage = true
+
+	fmt.Println("Step 1/4: Log in")
+	accessToken, err := initLogin(cmd, cfg, client, interactive)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Step 2/4: Project")
+	projectsResponse, err := client.ListProjects(accessToken)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+	projects := make([]initChoice, len(projectsResponse.Projects))
+	for i, project := range projectsResponse.Projects {
+		projects[i] = initChoice{id: project.ID, label: project.Name}
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("your account has no projects. Create one in the Flux Relay dashboard, then run 'flux-relay init' again")
+	}
+	project, err := chooseInitStep(initStep{"project", "flux-relay pr <project-name-or-id>"}, projects, cfg.GetSelectedProject(), interactive)
+	if err != nil {
+		return err
+	}
+	// Selecting a project clears the server, so only save a new one
+	if project.id != cfg.GetSelectedProject() {
+		if err := cfg.SetSelectedProject(project.id); err != nil {
+			return fmt.Errorf("failed to save project selection: %w", err)
+		}
+	}
+	fmt.Println(ui.Success("Selected project: %s (%s)", project.label, project.id))
+
+	fmt.Println()
+	fmt.Println("Step 3/4: Server")
+	serversResponse, err := client.ListServers(accessToken, project.id)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+		return fmt.Errorf("failed to list servers: %w", err)
+	}
+	servers := make([]initChoice, len(serversResponse.Servers))
+	for i, server := range serversResponse.Servers {
+		servers[i] = initChoice{id: server.ID, label: server.Name}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("project '%s' has no servers. Create one in the Flux Relay dashboard, then run 'flux-relay init' again", project.label)
+	}
+	server, err := chooseInitStep(initStep{"server", "flux-relay server <server-name-or-id>"}, servers, cfg.GetSelectedServer(), interactive)
+	if err != nil {
+		return err
+	}
+	// Selecting a server clears the nameserver, so only save a new one
+	if server.id != cfg.GetSelectedServer() {
+		if err := cfg.SetSelectedServer(server.id); err != nil {
+			return fmt.Errorf("failed to save server selection: %w", err)
+		}
+	}
+	fmt.Println(ui.Success("Selected server: %s (%s)", server.label, server.id))
+
+	fmt.Println()
+	fmt.Println("Step 4/4: Nameserver")
+	nameserver, err := initNameserver(cfg, client, accessToken, project.id, server, interactive)
+	if err != nil {
+		return err
+	}
+	if err := cfg.SetSelectedNameserver(nameserver.id); err != nil {
+		return fmt.Errorf("failed to save nameserver selection: %w", err)
+	}
+	fmt.Println(ui.Success("Selected nameserver: %s (%s)", nameserver.label, nameserver.id))
+
+	fmt.Println()
+	fmt.Println(ui.Success("Setup complete"))
+	fmt.Println()
+	fmt.Println("You can now use:")
+	fmt.Println("  flux-relay shell                # Open the interactive SQL shell")
+	fmt.Println("  flux-relay sql <query>          # Execute SQL query")
+	return nil
+}
+
+// initLogin returns the saved access token, logging in first when there is
+// none or the API rejects it
+func initLogin(cmd *cobra.Command, cfg *config.ConfigManager, client *api.Client, interactive bool) (string, error) {
+	accessToken := cfg.GetAccessToken()
+	// A token issued by another API is never sent to this one
+	if saved := cfg.GetAPIURL(); saved != "" && !sameAPIURL(saved, client.BaseURL) {
+		accessToken = ""
+	}
+	if accessToken != "" {
+		userInfo, err := client.GetCurrentUser(accessToken)
+		switch {
+		case err == nil:
+			fmt.Println(ui.Success("Logged in as %s", userInfo.Email()))
+			return accessToken, nil
+		case !api.IsUnauthorized(err):
+			return "", fmt.Errorf("failed to check your login: %w", err)
+		}
+		accessToken = ""
+	}
+
+	if !interactive {
+		return "", fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+	if err := runLogin(cmd, nil); err != nil {
+		return "", err
+	}
+	// Headless login ends before a token is saved
+	if accessToken = cfg.GetAccessToken(); accessToken == "" {
+		return "", fmt.Errorf("login was not completed. Once you're logged in, run 'flux-relay init' again")
+	}
+	return accessToken, nil
+}
+
+// chooseInitStep picks one of choices. A single choice is taken without
+// asking, and Enter keeps current if it is one of them. Without a terminal,
+// a valid current selection is kept and anything else is an error.
+func chooseInitStep(step initStep, choices []initChoice, current string, interactive bool) (initChoice, error) {
+	if len(choices) == 1 {
+		return choices[0], nil
+	}
+	currentIndex := -1
+	for i, choice := range choices {
+		if choice.id == current {
+			currentIndex = i
+		}
+	}
+
+	if !interactive {
+		if currentIndex >= 0 {
+			return choices[currentIndex], nil
+		}
+		return initChoice{}, fmt.Errorf("%d %ss to choose from and none selected. Select one with '%s', then run 'flux-relay init' again",
+			len(choices), step.kind, step.command)
+	}
+
+	for i, choice := range choices {
+		marker := ""
+		if i == currentIndex {
+			marker = "  (selected)"
+		}
+		fmt.Printf("  %d. %s (%s)%s\n", i+1, choice.label, choice.id, marker)
+	}
+	question := fmt.Sprintf("Choose a %s [1-%d]: ", step.kind, len(choices))
+	if currentIndex >= 0 {
+		question = fmt.Sprintf("Choose a %s [1-%d, Enter keeps %s]: ", step.kind, len(choices), choices[currentIndex].label)
+	}
+	for {
+		answer, err := askLine(question)
+		if err != nil {
+			return initChoice{}, fmt.Errorf("no %s chosen", step.kind)
+		}
+		if answer == "" && currentIndex >= 0 {
+			return choices[currentIndex], nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		fmt.Printf("Enter a number from 1 to %d.\n", len(choices))
+	}
+}
+
+// initNameserver picks an active nameserver of the server, or offers to
+// create and initialize one when it has none
+func initNameserver(cfg *config.ConfigManager, client *api.Client, accessToken, projectID string, server initChoice, interactive bool) (initChoice, error) {
+	databasesResponse, err := client.ListDatabases(accessToken, projectID, server.id)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return initChoice{}, errSessionExpired
+		}
+		return initChoice{}, fmt.Errorf("failed to list nameservers: %w", err)
+	}
+	var nameservers []initChoice
+	for _, db := range databasesResponse.Databases {
+		if db.IsActive {
+			nameservers = append(nameservers, initChoice{id: db.ID, label: db.DatabaseName})
+		}
+	}
+	if len(nameservers) > 0 {
+		return chooseInitStep(initStep{"nameserver", "flux-relay ns use <nameserver-name-or-id>"}, nameservers, cfg.GetSelectedNameserver(), interactive)
+	}
+
+	if !interactive {
+		return initChoice{}, fmt.Errorf("server '%s' has no active nameservers. Create one with 'flux-relay ns create <name> --select', then run 'flux-relay init' again", server.label)
+	}
+	fmt.Printf("Server '%s' has no active nameservers.\n", server.label)
+	if !askYesNo("Create one now?") {
+		return initChoice{}, fmt.Errorf("no nameserver selected. Create one with 'flux-relay ns create <name> --select'")
+	}
+
+	var name string
+	for {
+		name, err = askLine("Nameserver name: ")
+		if err != nil {
+			return initChoice{}, fmt.Errorf("no nameserver name given")
+		}
+		if err = validateNameserverName(name); err == nil {
+			break
+		}
+		fmt.Println(err)
+	}
+
+	fmt.Printf("Creating nameserver '%s'...\n", name)
+	response, err := client.CreateNameserver(accessToken, projectID, server.id, name)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return initChoice{}, errSessionExpired
+		}
+		return initChoice{}, fmt.Errorf("failed to create nameserver: %w", err)
+	}
+	created := initChoice{id: response.Database.ID, label: response.Database.DatabaseName}
+	fmt.Println(ui.Success("Nameserver created successfully!"))
+
+	initType := "messaging"
+	if configured := viper.GetString("default_schema_type"); validSchemaTypes[configured] {
+		initType = configured
+	}
+	if !askYesNo(fmt.Sprintf("Initialize its schema (%s) now?", initType)) {
+		fmt.Println(ui.Hint("Initialize it later with: flux-relay ns initialize %s", created.label))
+		return created, nil
+	}
+	initResponse, err := client.InitializeNameserverWithOptions(accessToken, projectID, server.id, created.id, initType, false)
+	switch {
+	case err != nil:
+		// The nameserver exists either way, so it is still selected
+		fmt.Println(ui.Error("Schema initialization failed: %v", err))
+		fmt.Println(ui.Hint("Retry with: flux-relay ns initialize %s", created.label))
+	case len(missingTables(initResponse)) > 0:
+		fmt.Println(ui.Warn("Schema initialization incomplete: %d of %d table(s) missing", len(missingTables(initResponse)), len(initResponse.AllTables)))
+		fmt.Println(ui.Hint("Retry with: flux-relay ns initialize %s", created.label))
+	default:
+		rememberSchemaType(cfg, created.id, initType, initResponse)
+		fmt.Println(ui.Success("Schema initialized successfully!"))
+	}
+	return created, nil
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -40,13 +41,13 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	// Check if Go is installed
 	goInstalled := checkGoInstalled()
 	if goInstalled {
-		fmt.Println("✅ Go found - using 'go install' method")
+		fmt.Println(ui.Success("Go found - using 'go install' method"))
 		fmt.Println()
 		return installViaGo()
 	}
 
 	// Fall back to platform-specific installer
-	fmt.Println("⚠️  Go not found - using platform-specific installer")
+	fmt.Println(ui.Warn("Go not found - using platform-specific installer"))
 	fmt.Println()
 	return installViaScript()
 }
@@ -74,7 +75,7 @@ func installViaGo() error {
 	}
 
 	fmt.Println()
-	fmt.Println("✅ Installation complete!")
+	fmt.Println(ui.Success("Installation complete!"))
 	fmt.Println()
 	
 	// Find where Go installed it
@@ -106,7 +107,7 @@ func installViaGo() error {
 			}
 			
 			if !inPath {
-				fmt.Println("⚠️  Warning: The Go bin directory is not in your PATH")
+				fmt.Println(ui.Warn("Warning: The Go bin directory is not in your PATH"))
 				fmt.Println()
 				fmt.Printf("Add this to your ~/.bashrc or ~/.zshrc:\n")
 				fmt.Printf("  export PATH=\"$PATH:%s\"\n", binDir)
@@ -117,7 +118,7 @@ func installViaGo() error {
 				fmt.Println("Or add it temporarily for this session:")
 				fmt.Printf("  export PATH=\"$PATH:%s\"\n", binDir)
 			} else {
-				fmt.Println("✅ Go bin directory is already in your PATH")
+				fmt.Println(ui.Success("Go bin directory is already in your PATH"))
 			}
 		}
 		
@@ -177,6 +178,6 @@ func installViaScript() error {
 	}
 
 	fmt.Println()
-	fmt.Println("✅ Installation complete!")
+	fmt.Println(ui.Success("Installation complete!"))
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -58,6 +59,16 @@ func printLogo() {
 	fmt.Print(logo)
 }
 
+// sameAPIURL reports whether two API URLs name the same API
+func sameAPIURL(a, b string) bool {
+	normalizedA, errA := api.NormalizeBaseURL(a)
+	normalizedB, errB := api.NormalizeBaseURL(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return normalizedA == normalizedB
+}
+
 func runLogin(cmd *cobra.Command, args []string) error {
 	// Get API URL from flag, config, or default
 	apiURL := getAPIURL()
@@ -65,6 +76,10 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	// Check if already logged in
 	cfg := config.New()
 	accessToken := cfg.GetAccessToken()
+	// Don't send a token issued by another API to this one
+	if saved := cfg.GetAPIURL(); saved != "" && !sameAPIURL(saved, apiURL) {
+		accessToken = ""
+	}
 	if accessToken != "" {
 		// Try to validate the token by getting user info
 		client := api.NewClient(apiURL)
@@ -145,7 +160,7 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	}
 
 	// Step 4: Save token
-	if err := cfg.SaveToken(tokenResponse); err != nil {
+	if err := cfg.SaveToken(tokenResponse, apiURL); err != nil {
 		return fmt.Errorf("failed to save token: %w", err)
 	}
 
@@ -342,7 +357,7 @@ func pollForToken(client *api.Client, deviceCode string, interval int, verificat
 				}
 				fmt.Println() // New line
 				fmt.Println()
-				fmt.Println("⚠️  Device code not found after multiple attempts.")
+				fmt.Println(ui.Warn("Device code not found after multiple attempts."))
 				fmt.Println()
 				fmt.Println("This usually means:")
 				fmt.Println("   1. You haven't opened the verification URL in your browser yet")
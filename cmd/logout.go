@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -11,21 +14,56 @@ var logoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Log out from Flux Relay",
 	Long: `Log out from Flux Relay by removing the stored access token.
-You will need to run 'flux-relay login' again to authenticate.`,
+You will need to run 'flux-relay login' again to authenticate.
+
+Options:
+  --keep-selections: Remove only the token and keep the selected project,
+                     server and nameserver for your next login
+  --all:             Remove the login, history, snippets, audit log and settings
+                     the CLI keeps in the config directory (~/.flux-relay, or
+                     --config-dir; asks for confirmation).
+                     Other files in the directory are left alone
+
+Examples:
+  flux-relay logout
+  flux-relay logout --keep-selections
+  flux-relay logout --all`,
 	RunE: runLogout,
 }
 
+var logoutAll bool
+var logoutKeepSelections bool
+
 func init() {
+	logoutCmd.Flags().BoolVar(&logoutAll, "all", false, "Remove all saved configuration and data the CLI keeps in the config directory")
+	logoutCmd.Flags().BoolVar(&logoutKeepSelections, "keep-selections", false, "Remove only the token; keep project/server/nameserver selections for the next login")
 	rootCmd.AddCommand(logoutCmd)
 }
 
 func runLogout(cmd *cobra.Command, args []string) error {
+	if logoutAll && logoutKeepSelections {
+		return fmt.Errorf("--all and --keep-selections cannot be used together")
+	}
+
 	cfg := config.New()
 
+	if logoutAll {
+		return runLogoutAll(cfg)
+	}
+
 	// Check if token exists
 	token, err := cfg.GetToken()
 	if err != nil || token == nil {
-		fmt.Println("ℹ️  No active session found. You are already logged out.")
+		fmt.Println(ui.Info("No active session found. You are already logged out."))
+		return nil
+	}
+
+	if logoutKeepSelections {
+		if err := cfg.ClearToken(); err != nil {
+			return fmt.Errorf("failed to remove token: %w", err)
+		}
+		fmt.Println(ui.Success("Logged out successfully"))
+		fmt.Println("   Your selections were kept and will be restored when you log in again.")
 		return nil
 	}
 
@@ -34,8 +72,46 @@ func runLogout(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to remove token: %w", err)
 	}
 
-	fmt.Println("✅ Logged out successfully")
+	fmt.Println(ui.Success("Logged out successfully"))
 	fmt.Println("   Token removed from:", cfg.ConfigPath())
 
 	return nil
 }
+
+// runLogoutAll removes the CLI's files from the config directory after
+// confirmation. Other files there are kept, since --config-dir may name any
+// directory.
+func runLogoutAll(cfg *config.ConfigManager) error {
+	dir := cfg.ConfigDir()
+	files, err := cfg.OwnedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		fmt.Println(ui.Info("Nothing to remove. You are already logged out."))
+		return nil
+	}
+
+	if !assumingYes() {
+		fmt.Printf("This will remove these files from %s:\n", dir)
+		for _, path := range files {
+			fmt.Printf("  - %s\n", filepath.Base(path))
+		}
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to remove files from %s without confirmation. Pass --yes to confirm", dir)
+		}
+		if !confirm("Continue?") {
+			fmt.Println("Aborted. Nothing was removed.")
+			return nil
+		}
+	}
+
+	if err := cfg.RemoveAll(); err != nil {
+		return fmt.Errorf("failed to remove files from %s: %w", dir, err)
+	}
+
+	fmt.Println(ui.Success("Logged out and removed all saved data"))
+	fmt.Printf("   Removed %d file(s) from %s\n", len(files), dir)
+
+	return nil
+}
@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var nsCmd = &cobra.Command{
@@ -19,30 +25,96 @@ var nsCmd = &cobra.Command{
 
 Examples:
   flux-relay ns list              # List all nameservers
-  flux-relay ns db                # Select by name
-  flux-relay ns db_123            # Select by ID
-  flux-relay ns                   # Show current nameserver`,
+  flux-relay ns use db            # Select by name
+  flux-relay ns use db_123        # Select by ID
+  flux-relay ns current           # Show current nameserver
+
+'flux-relay ns <name-or-id>' and a bare 'flux-relay ns' still select and show
+the nameserver, as before.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runNsShowOrSelect,
+	// Used to point out mistyped subcommands such as 'ns lst'
+	SuggestionsMinimumDistance: 2,
+}
+
+var nsUseCmd = &cobra.Command{
+	Use:   "use <nameserver-name-or-id>",
+	Short: "Select a nameserver",
+	Long: `Select the nameserver that sql and the shell use by default.
+
+Examples:
+  flux-relay ns use db
+  flux-relay ns use db_123`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNsUse,
+}
+
+var nsCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the selected nameserver",
+	Args:  cobra.NoArgs,
+	RunE:  runNsCurrent,
 }
 
 var nsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all nameservers in the selected server",
-	Long:  "List all nameservers (databases) in the currently selected server",
-	RunE:  runNsList,
+	Long: `List all nameservers (databases) in the currently selected server.
+
+With --all-servers, list the nameservers of every server in the selected
+project, with the server each belongs to.
+
+--filter shows only active or only inactive (soft-deleted) nameservers. An
+inactive nameserver still holds its name, so creating one with the same name
+reactivates it instead.
+
+Examples:
+  flux-relay ns list
+  flux-relay ns list --filter inactive
+  flux-relay ns list --all-servers
+  flux-relay ns list --all-servers -o json`,
+	RunE: runNsList,
+}
+
+var (
+	nsListAllServers bool
+	nsListFilter     string
+)
+
+// nsListFilters maps ns list --filter to whether a nameserver with the given
+// IsActive is shown
+var nsListFilters = map[string]func(isActive bool) bool{
+	"all":      func(bool) bool { return true },
+	"active":   func(isActive bool) bool { return isActive },
+	"inactive": func(isActive bool) bool { return !isActive },
 }
 
+var nsDatabaseURL string
+var nsDatabaseToken string
+var nsCreateIfNotExists bool
+var nsCreateSelect bool
+
 var nsShellCmd = &cobra.Command{
 	Use:   "shell [nameserver-name-or-id]",
 	Short: "Open interactive SQL shell for a nameserver",
 	Long: `Open an interactive SQL shell for a nameserver, similar to Turso's shell.
 
+If no nameserver is specified, the currently selected nameserver is used.
+
 Examples:
   flux-relay ns shell db
-  flux-relay ns shell db_123`,
-	Args: cobra.ExactArgs(1),
+  flux-relay ns shell db_123
+  flux-relay ns shell                 # Use the selected nameserver`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+			return err
+		}
+		// A statement that fails in a piped script is already reported
+		cmd.SilenceUsage = true
+		if len(args) == 0 {
+			return runNameserverShell("")
+		}
 		return runNameserverShell(args[0])
 	},
 }
@@ -52,11 +124,27 @@ var nsCreateCmd = &cobra.Command{
 	Short: "Create a new nameserver (database)",
 	Long: `Create a new nameserver (database) in the selected server.
 
-The nameserver name must be 1-100 characters and can contain letters, numbers, and underscores.
+The nameserver name must be 1-100 characters, start with a letter, and contain
+only letters, numbers, and underscores, since it becomes the table suffix
+(e.g. conversations_<name>).
+
+To attach an existing Turso/libSQL database instead of provisioning a new
+one, pass its URL and auth token together. Use --database-token - to read the
+token from stdin so it stays out of your shell history.
+
+With --if-not-exists, an existing active nameserver of the same name (names
+are case-insensitive) is reported with its ID and nothing is created, so
+provisioning scripts can run repeatedly. An inactive one is reactivated.
+
+--select selects the new nameserver, as 'ns use' would. It requires the
+nameserver's server to be the selected server.
 
 Examples:
   flux-relay ns create db
-  flux-relay ns create my_database`,
+  flux-relay ns create my_database
+  flux-relay ns create analytics --database-url libsql://analytics-org.turso.io --database-token - < token.txt
+  flux-relay ns create db --if-not-exists
+  flux-relay ns create db --select`,
 	Args: cobra.ExactArgs(1),
 	RunE: runNsCreate,
 }
@@ -71,120 +159,205 @@ If no nameserver is specified, uses the currently selected nameserver.
 
 Options:
   --type: Schema type - 'messaging' (default), 'analytics', or 'both'
+  --schema-file: Create tables from a local .sql file instead of a built-in schema.
+                 Unsuffixed table names are rewritten to the nameserver's suffix,
+                 and every CREATE TABLE must end with it (e.g. messages_db).
   --drop-existing: Drop existing tables before creating new ones (use with caution!)
 
+If initialization only partly succeeds, the created and missing tables are
+listed and you are offered a retry. Retries never drop existing tables.
+
 Examples:
   flux-relay ns initialize              # Initialize current nameserver
   flux-relay ns initialize db           # Initialize specific nameserver
-  flux-relay ns initialize --type both # Initialize with messaging + analytics`,
+  flux-relay ns initialize --type both # Initialize with messaging + analytics
+  flux-relay ns initialize db --schema-file schema.sql`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runNsInitialize,
 }
 
+var nsDescribeCmd = &cobra.Command{
+	Use:   "describe [nameserver-name-or-id]",
+	Short: "Show all details of a nameserver",
+	Long: `Show every field of a nameserver, including timestamps, token status
+and database URL (with tokens masked).
+
+If no nameserver is specified, the currently selected nameserver is described.
+
+Examples:
+  flux-relay ns describe
+  flux-relay ns describe db`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNsDescribe,
+}
+
+var nsReactivateCmd = &cobra.Command{
+	Use:   "reactivate <nameserver-name-or-id>",
+	Short: "Make an inactive (soft-deleted) nameserver active again",
+	Long: `Make an inactive (soft-deleted) nameserver active again. Its database and
+tables are kept while it is inactive, so they are back as they were.
+
+An inactive nameserver still holds its name; 'flux-relay ns list --filter
+inactive' lists them. A nameserver that is already active is left as is.
+
+Examples:
+  flux-relay ns reactivate db
+  flux-relay ns reactivate db --server staging`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNsReactivate,
+}
+
 var schemaType string
+
+// validSchemaTypes are the built-in schemas 'ns initialize' can create
+var validSchemaTypes = map[string]bool{
+	"messaging": true,
+	"analytics": true,
+	"both":      true,
+}
+var schemaFile string
 var dropExisting bool
 
 func init() {
+	// JSON and YAML only with --all-servers
+	setOutputFormats(nsListCmd, render.FormatJSON, render.FormatYAML)
 	nsCmd.AddCommand(nsListCmd)
+	nsCmd.AddCommand(nsUseCmd)
+	nsCmd.AddCommand(nsCurrentCmd)
 	nsCmd.AddCommand(nsShellCmd)
 	nsCmd.AddCommand(nsCreateCmd)
 	nsCmd.AddCommand(nsInitializeCmd)
+	nsCmd.AddCommand(nsDescribeCmd)
+	nsCmd.AddCommand(nsReactivateCmd)
 	
 	// Flags for initialize command
-	nsInitializeCmd.Flags().StringVar(&schemaType, "type", "messaging", "Schema type: 'messaging', 'analytics', or 'both'")
+	nsListCmd.Flags().BoolVar(&nsListAllServers, "all-servers", false, "List nameservers of every server in the project")
+	nsListCmd.Flags().StringVar(&nsListFilter, "filter", "all", "Show only active, inactive or all nameservers")
+	nsCreateCmd.Flags().StringVar(&nsDatabaseURL, "database-url", "", "URL of an existing libSQL database to attach (requires --database-token)")
+	nsCreateCmd.Flags().StringVar(&nsDatabaseToken, "database-token", "", "Auth token for --database-url, or '-' to read it from stdin")
+	nsCreateCmd.Flags().BoolVar(&nsCreateSelect, "select", false, "Select the nameserver once created, like 'ns use'")
+	nsCreateCmd.Flags().BoolVar(&nsCreateIfNotExists, "if-not-exists", false, "Succeed without creating anything if an active nameserver of this name exists")
+
+	nsInitializeCmd.Flags().StringVar(&schemaType, "type", "messaging", "Schema type: 'messaging', 'analytics', or 'both'; overrides default_schema_type in settings")
+	nsInitializeCmd.Flags().StringVar(&schemaFile, "schema-file", "", "Path to a .sql file with a custom schema to apply")
 	nsInitializeCmd.Flags().BoolVar(&dropExisting, "drop-existing", false, "Drop existing tables before creating new ones")
 	
 	rootCmd.AddCommand(nsCmd)
 }
 
+// runNsShowOrSelect handles the bare 'ns' and 'ns <name-or-id>' forms
 func runNsShowOrSelect(cmd *cobra.Command, args []string) error {
-	// Get API URL
-	apiURL := getAPIURL()
+	if len(args) == 0 {
+		return runNsCurrent(cmd, args)
+	}
 
-	// Get access token
-	cfg := config.New()
-	accessToken := cfg.GetAccessToken()
+	err := runNsUse(cmd, args)
+	if err != nil {
+		// 'ns lst' is more likely a mistyped subcommand than a nameserver name
+		if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+			return fmt.Errorf("%w\nDid you mean 'flux-relay ns %s'?", err, suggestions[0])
+		}
+	}
+	return err
+}
+
+// nsSelection returns the access token and the selected project and server
+// that nameservers are shown and selected in
+func nsSelection(cfg *config.ConfigManager) (accessToken, projectID, serverID string, err error) {
+	accessToken = cfg.GetAccessToken()
 	if accessToken == "" {
-		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+		return "", "", "", fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project and server
-	projectID := cfg.GetSelectedProject()
+	projectID = cfg.GetSelectedProject()
 	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+		return "", "", "", fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
 	}
 
-	serverID := cfg.GetSelectedServer()
+	serverID = cfg.GetSelectedServer()
 	if serverID == "" {
-		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
+		return "", "", "", fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
 	}
 
-	// If no argument, show current nameserver
-	if len(args) == 0 {
-		selectedNameserverID := cfg.GetSelectedNameserver()
-		if selectedNameserverID == "" {
-			fmt.Println("No nameserver selected.")
-			fmt.Println()
-			fmt.Println("Select a nameserver using:")
-			fmt.Println("  flux-relay ns <nameserver-name-or-id>")
-			fmt.Println()
-			fmt.Println("Or list available nameservers:")
-			fmt.Println("  flux-relay ns list")
-			return nil
-		}
+	return accessToken, projectID, serverID, nil
+}
 
-		// Get nameserver details
-		client := api.NewClient(apiURL)
-		databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
-		if err != nil {
-			return fmt.Errorf("failed to get nameserver info: %w", err)
-		}
+// runNsCurrent shows the selected nameserver
+func runNsCurrent(cmd *cobra.Command, args []string) error {
+	if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+		return err
+	}
 
-		// Find the selected nameserver
-		var selectedNameserver *api.Database
-		for i := range databasesResponse.Databases {
-			if databasesResponse.Databases[i].ID == selectedNameserverID {
-				selectedNameserver = &databasesResponse.Databases[i]
-				break
-			}
-		}
+	// Get API URL
+	apiURL := getAPIURL()
 
-		if selectedNameserver == nil {
-			fmt.Printf("⚠️  Selected nameserver (ID: %s) not found.\n", selectedNameserverID)
-			fmt.Println("Please select a different nameserver.")
-			return nil
-		}
+	cfg := config.New()
+	accessToken, projectID, serverID, err := nsSelection(cfg)
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("Current nameserver: %s (%s)\n", selectedNameserver.DatabaseName, selectedNameserver.ID)
+	selectedNameserverID := cfg.GetSelectedNameserver()
+	if selectedNameserverID == "" {
+		fmt.Println("No nameserver selected.")
+		fmt.Println()
+		fmt.Println("Select a nameserver using:")
+		fmt.Println("  flux-relay ns use <nameserver-name-or-id>")
 		fmt.Println()
-		fmt.Println("You can now use:")
-		fmt.Println("  flux-relay sql <query>          # Execute SQL query")
+		fmt.Println("Or list available nameservers:")
+		fmt.Println("  flux-relay ns list")
 		return nil
 	}
 
-	// If argument provided, treat as nameserver selection
-	nameserverIdentifier := strings.Join(args, " ")
-
-	// Get all nameservers
+	// Get nameserver details
 	client := api.NewClient(apiURL)
 	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
 	if err != nil {
-		return fmt.Errorf("failed to list nameservers: %w", err)
+		return fmt.Errorf("failed to get nameserver info: %w", checkSession(err))
 	}
 
-	// Find nameserver by ID or name (case-insensitive)
+	// Find the selected nameserver
 	var selectedNameserver *api.Database
 	for i := range databasesResponse.Databases {
-		ns := &databasesResponse.Databases[i]
-		if ns.ID == nameserverIdentifier || 
-		   strings.EqualFold(ns.DatabaseName, nameserverIdentifier) {
-			selectedNameserver = ns
+		if databasesResponse.Databases[i].ID == selectedNameserverID {
+			selectedNameserver = &databasesResponse.Databases[i]
 			break
 		}
 	}
 
 	if selectedNameserver == nil {
-		return fmt.Errorf("nameserver '%s' not found. Use 'flux-relay ns list' to see available nameservers", nameserverIdentifier)
+		fmt.Println(ui.Warn("Selected nameserver (ID: %s) not found.", selectedNameserverID))
+		fmt.Println("Please select a different nameserver.")
+		return nil
+	}
+
+	fmt.Printf("Current nameserver: %s (%s)\n", selectedNameserver.DatabaseName, selectedNameserver.ID)
+	fmt.Println()
+	fmt.Println("You can now use:")
+	fmt.Println("  flux-relay sql <query>          # Execute SQL query")
+	return nil
+}
+
+// runNsUse selects a nameserver by name or ID
+func runNsUse(cmd *cobra.Command, args []string) error {
+	if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+		return err
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	cfg := config.New()
+	accessToken, projectID, serverID, err := nsSelection(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Find nameserver by ID or name (case-insensitive)
+	client := api.NewClient(apiURL)
+	selectedNameserver, err := resolveNameserver(client, accessToken, projectID, serverID, args[0])
+	if err != nil {
+		return err
 	}
 
 	// Save selected nameserver
@@ -192,7 +365,7 @@ func runNsShowOrSelect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save nameserver selection: %w", err)
 	}
 
-	fmt.Printf("✅ Selected nameserver: %s (%s)\n", selectedNameserver.DatabaseName, selectedNameserver.ID)
+	fmt.Println(ui.Success("Selected nameserver: %s (%s)", selectedNameserver.DatabaseName, selectedNameserver.ID))
 	fmt.Println()
 	fmt.Println("You can now use:")
 	fmt.Println("  flux-relay sql <query>          # Execute SQL query")
@@ -200,7 +373,7 @@ func runNsShowOrSelect(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runNsList(cmd *cobra.Command, args []string) error {
+func runNsDescribe(cmd *cobra.Command, args []string) error {
 	// Get API URL
 	apiURL := getAPIURL()
 
@@ -211,33 +384,168 @@ func runNsList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project and server
-	projectID := cfg.GetSelectedProject()
-	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
 	}
 
-	serverID := cfg.GetSelectedServer()
-	if serverID == "" {
-		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+
+	// The argument takes precedence over --nameserver and the saved selection
+	if len(args) == 1 {
+		nameserverOverride = args[0]
+	}
+	nameserverID, err := currentNameserverID(cfg, client, accessToken, projectID, serverID)
+	if err != nil {
+		return err
+	}
+	if nameserverID == "" {
+		return fmt.Errorf("no nameserver selected. Use 'flux-relay ns use <nameserver-name-or-id>' or pass a nameserver name")
+	}
+	ns, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Nameserver: %s\n", ns.DatabaseName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "   ID:\t%s\n", ns.ID)
+	fmt.Fprintf(w, "   Server:\t%s\n", serverID)
+	fmt.Fprintf(w, "   Status:\t%s\n", activeStatus(ns.IsActive))
+	fmt.Fprintf(w, "   Token:\t%s\n", yesNo(ns.HasToken))
+	fmt.Fprintf(w, "   Database URL:\t%s\n", valueOrDash(maskURLSecrets(ns.DatabaseURL)))
+	fmt.Fprintf(w, "   Table suffix:\t_%s\n", ns.DatabaseName)
+	fmt.Fprintf(w, "   Created:\t%s\n", valueOrDash(ns.CreatedAt))
+	fmt.Fprintf(w, "   Updated:\t%s\n", valueOrDash(ns.UpdatedAt))
+	w.Flush()
+
+	return nil
+}
+
+func runNsReactivate(cmd *cobra.Command, args []string) error {
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+
+	// The API lists inactive nameservers too, so they resolve by name or ID
+	ns, err := resolveNameserver(client, accessToken, projectID, serverID, args[0])
+	if err != nil {
+		return err
+	}
+	if ns.IsActive {
+		fmt.Println(ui.Info("Nameserver '%s' (ID: %s) is already active. Nothing to do.", ns.DatabaseName, ns.ID))
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+	reactivated, err := client.ReactivateNameserver(accessToken, projectID, serverID, ns.ID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+		if apiErr, ok := err.(*api.APIError); ok {
+			return fmt.Errorf("API error: %w", apiErr)
+		}
+		return fmt.Errorf("failed to reactivate nameserver: %w", err)
+	}
+	// The nameserver is only echoed back by some API versions
+	if reactivated.ID != "" && !reactivated.IsActive {
+		return fmt.Errorf("the API accepted the request but nameserver '%s' is still inactive", ns.DatabaseName)
+	}
+
+	fmt.Println(ui.Success("Nameserver '%s' reactivated", ns.DatabaseName))
+	fmt.Printf("   ID: %s\n", ns.ID)
+	fmt.Println()
+	fmt.Println("To select it, run:")
+	fmt.Printf("  flux-relay ns use %s\n", ns.DatabaseName)
+	return nil
+}
+
+func runNsList(cmd *cobra.Command, args []string) error {
+	shown, ok := nsListFilters[nsListFilter]
+	if !ok {
+		return fmt.Errorf("invalid --filter '%s'. Must be 'active', 'inactive' or 'all'", nsListFilter)
+	}
+	if structuredOutput() && !nsListAllServers {
+		return fmt.Errorf("'%s' prints %s only with --all-servers", cmd.CommandPath(), outputFormat)
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Create API client and list nameservers
 	client := api.NewClient(apiURL)
+
+	// Get selected project and server (or the --project/--server overrides)
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if nsListAllServers {
+		if serverOverride != "" {
+			return fmt.Errorf("--all-servers cannot be used with --server")
+		}
+		return runNsListAllServers(client, accessToken, projectID, shown)
+	}
+
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+
+	// List nameservers
 	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
 	if err != nil {
 		if apiErr, ok := err.(*api.APIError); ok {
-			if apiErr.Code() == "Unauthorized" || apiErr.Code() == "unauthorized" {
-				return fmt.Errorf("authentication failed. Please run 'flux-relay login' again")
+			if api.IsUnauthorized(apiErr) {
+				return errSessionExpired
 			}
 			return fmt.Errorf("API error: %w", apiErr)
 		}
 		return fmt.Errorf("failed to list nameservers: %w", err)
 	}
 
-	nameservers := databasesResponse.Databases
+	var nameservers []api.Database
+	for _, ns := range databasesResponse.Databases {
+		if shown(ns.IsActive) {
+			nameservers = append(nameservers, ns)
+		}
+	}
 
 	if len(nameservers) == 0 {
+		if nsListFilter != "all" {
+			fmt.Printf("No %s nameservers found in this server.\n", nsListFilter)
+			return nil
+		}
 		fmt.Println("No nameservers found in this server.")
 		fmt.Println()
 		fmt.Println("Create a nameserver using the web dashboard or API.")
@@ -279,6 +587,153 @@ func runNsList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// nsListServer is a server and its nameservers in ns list --all-servers -o json
+type nsListServer struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	IsActive    bool               `json:"isActive"`
+	Nameservers []nsListNameserver `json:"nameservers"`
+	Error       string             `json:"error,omitempty"`
+}
+
+type nsListNameserver struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	IsActive  bool   `json:"isActive"`
+}
+
+// runNsListAllServers lists the nameservers of every server in a project,
+// fetching each server's nameservers in parallel. Only nameservers for which
+// shown returns true are listed.
+func runNsListAllServers(client *api.Client, accessToken, projectID string, shown func(isActive bool) bool) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+
+	serversResponse, err := client.ListServers(accessToken, projectID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+		if apiErr, ok := err.(*api.APIError); ok {
+			return fmt.Errorf("API error: %w", apiErr)
+		}
+		return fmt.Errorf("failed to list servers: %w", err)
+	}
+	servers := serversResponse.Servers
+
+	var wg sync.WaitGroup
+	results := make([]nsListServer, len(servers))
+	errs := make([]error, len(servers))
+
+	for i, server := range servers {
+		wg.Add(1)
+		go func(idx int, srv api.Server) {
+			defer wg.Done()
+			results[idx] = nsListServer{
+				ID:          srv.ID,
+				Name:        srv.Name,
+				IsActive:    srv.IsActive,
+				Nameservers: []nsListNameserver{},
+			}
+			databasesResponse, err := client.ListDatabases(accessToken, projectID, srv.ID)
+			if err != nil {
+				errs[idx] = err
+				results[idx].Error = err.Error()
+				return
+			}
+			for _, db := range databasesResponse.Databases {
+				if !shown(db.IsActive) {
+					continue
+				}
+				results[idx].Nameservers = append(results[idx].Nameservers, nsListNameserver{
+					ID:        db.ID,
+					Name:      db.DatabaseName,
+					CreatedAt: db.CreatedAt,
+					IsActive:  db.IsActive,
+				})
+			}
+		}(i, server)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+	}
+
+	if structuredOutput() {
+		return printStructured(os.Stdout, struct {
+			ProjectID string         `json:"projectId"`
+			Servers   []nsListServer `json:"servers"`
+		}{projectID, results}, "nameservers")
+	}
+
+	if len(servers) == 0 {
+		fmt.Println("No servers found in this project.")
+		return nil
+	}
+
+	total := 0
+	for _, server := range results {
+		total += len(server.Nameservers)
+	}
+	fmt.Printf("Found %d nameserver(s) across %d server(s) in project:\n\n", total, len(servers))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tID\tNAME\tCREATED\tSTATUS")
+	fmt.Fprintln(w, "──────\t──\t────\t───────\t──────")
+
+	for _, server := range results {
+		if server.Error != "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\tError: %s\n", server.Name, server.Error)
+			continue
+		}
+		if len(server.Nameservers) == 0 {
+			fmt.Fprintf(w, "%s\t-\t(none)\t-\t-\n", server.Name)
+			continue
+		}
+		for _, ns := range server.Nameservers {
+			createdStr := ns.CreatedAt
+			if createdAt, err := time.Parse(time.RFC3339, ns.CreatedAt); err == nil {
+				createdStr = createdAt.Format("2006-01-02")
+			}
+			status := "Active"
+			if !ns.IsActive {
+				status = "Inactive"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", server.Name, ns.ID, ns.Name, createdStr, status)
+		}
+	}
+
+	w.Flush()
+	fmt.Println()
+
+	return nil
+}
+
+// nameserverNamePattern matches names that are legal as a table name suffix
+var nameserverNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// validateNameserverName checks that a nameserver name can be used in table
+// names such as conversations_<name>
+func validateNameserverName(name string) error {
+	if name == "" {
+		return fmt.Errorf("nameserver name cannot be empty")
+	}
+	if len(name) > 100 {
+		return fmt.Errorf("nameserver name must be 1-100 characters")
+	}
+	if !nameserverNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid nameserver name '%s': names become table suffixes (e.g. conversations_<name>), "+
+			"so they must start with a letter and contain only letters, digits and underscores", name)
+	}
+	return nil
+}
+
 func runNsCreate(cmd *cobra.Command, args []string) error {
 	// Get API URL
 	apiURL := getAPIURL()
@@ -290,53 +745,181 @@ func runNsCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project and server
-	projectID := cfg.GetSelectedProject()
-	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+	client := api.NewClient(apiURL)
+
+	// Get selected project and server (or the --project/--server overrides)
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
 	}
 
-	serverID := cfg.GetSelectedServer()
-	if serverID == "" {
-		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
 	}
 
 	nameserverName := strings.TrimSpace(args[0])
-	if nameserverName == "" {
-		return fmt.Errorf("nameserver name cannot be empty")
+	if err := validateNameserverName(nameserverName); err != nil {
+		return err
 	}
 
-	// Validate name format (1-100 chars, alphanumeric + underscore)
-	if len(nameserverName) < 1 || len(nameserverName) > 100 {
-		return fmt.Errorf("nameserver name must be 1-100 characters")
+	// A nameserver can only be selected within the selected server
+	if nsCreateSelect && (cfg.GetSelectedProject() != projectID || cfg.GetSelectedServer() != serverID) {
+		return fmt.Errorf("--select needs the nameserver's server to be the selected one. Select it with 'flux-relay server <server-name-or-id>' first, or leave out --select")
 	}
 
-	// Create API client and create nameserver
-	client := api.NewClient(apiURL)
+	databaseURL, databaseToken, err := externalDatabaseFlags()
+	if err != nil {
+		return err
+	}
+
+	if nsCreateIfNotExists {
+		existing, err := findNameserverByName(client, accessToken, projectID, serverID, nameserverName)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing == nil:
+		case existing.IsActive:
+			fmt.Println(ui.Info("Nameserver '%s' already exists; nothing to do", existing.DatabaseName))
+			fmt.Printf("   Name: %s\n", existing.DatabaseName)
+			fmt.Printf("   ID: %s\n", existing.ID)
+			if existing.DatabaseName != nameserverName {
+				fmt.Printf("   Names are case-insensitive; the existing name '%s' is kept.\n", existing.DatabaseName)
+			}
+			if databaseURL != "" {
+				fmt.Println("   --database-url and --database-token were not used.")
+			}
+			if nsCreateSelect {
+				return selectCreatedNameserver(cfg, existing.ID, existing.DatabaseName)
+			}
+			return nil
+		default:
+			// Creating a nameserver with the name of an inactive one
+			// reactivates it
+			fmt.Println(ui.Info("Found inactive nameserver '%s' (ID: %s) - it will be reactivated.", existing.DatabaseName, existing.ID))
+		}
+	}
+
+	// Create nameserver
 	fmt.Printf("Creating nameserver '%s'...\n", nameserverName)
 	
-	response, err := client.CreateNameserver(accessToken, projectID, serverID, nameserverName)
+	response, err := client.CreateNameserverWithDatabase(accessToken, projectID, serverID, nameserverName, databaseURL, databaseToken)
 	if err != nil {
 		if apiErr, ok := err.(*api.APIError); ok {
-			if apiErr.Code() == "Unauthorized" || apiErr.Code() == "unauthorized" {
-				return fmt.Errorf("authentication failed. Please run 'flux-relay login' again")
+			if api.IsUnauthorized(apiErr) {
+				return errSessionExpired
 			}
 			return fmt.Errorf("API error: %w", apiErr)
 		}
 		return fmt.Errorf("failed to create nameserver: %w", err)
 	}
 
-	fmt.Printf("✅ Nameserver created successfully!\n")
+	fmt.Println(ui.Success("Nameserver created successfully!"))
 	fmt.Printf("   Name: %s\n", response.Database.DatabaseName)
 	fmt.Printf("   ID: %s\n", response.Database.ID)
+	if databaseURL != "" {
+		fmt.Printf("   Database URL: %s\n", maskURLSecrets(databaseURL))
+		fmt.Printf("   Database token: %s\n", maskToken(databaseToken))
+	}
 	fmt.Println()
+	if nsCreateSelect {
+		if err := selectCreatedNameserver(cfg, response.Database.ID, response.Database.DatabaseName); err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Println("Next step: flux-relay ns initialize", response.Database.DatabaseName)
+		return nil
+	}
 	fmt.Println("Next steps:")
-	fmt.Println("  1. Select this nameserver: flux-relay ns", response.Database.DatabaseName)
+	fmt.Println("  1. Select this nameserver: flux-relay ns use", response.Database.DatabaseName)
 	fmt.Println("  2. Initialize schema: flux-relay ns initialize", response.Database.DatabaseName)
 
 	return nil
 }
 
+// selectCreatedNameserver saves the nameserver of ns create --select as the
+// selected one
+func selectCreatedNameserver(cfg *config.ConfigManager, id, name string) error {
+	if err := cfg.SetSelectedNameserver(id); err != nil {
+		return fmt.Errorf("failed to select nameserver '%s': %w", name, err)
+	}
+	fmt.Println(ui.Success("Selected nameserver: %s (%s)", name, id))
+	return nil
+}
+
+// findNameserverByName returns the server's nameserver, active or not, whose
+// name matches case-insensitively like the API's names do, or nil if there
+// is none
+func findNameserverByName(client *api.Client, accessToken, projectID, serverID, name string) (*api.Database, error) {
+	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("failed to list nameservers: %w", err)
+	}
+	var found *api.Database
+	for i, db := range databasesResponse.Databases {
+		if !strings.EqualFold(db.DatabaseName, name) {
+			continue
+		}
+		// Prefer an active nameserver over an inactive one of the same name
+		if found == nil || (db.IsActive && !found.IsActive) {
+			found = &databasesResponse.Databases[i]
+		}
+	}
+	return found, nil
+}
+
+// externalDatabaseFlags returns the --database-url and --database-token of
+// ns create. They must be given together; a token of "-" is read from stdin.
+func externalDatabaseFlags() (string, string, error) {
+	databaseURL := strings.TrimSpace(nsDatabaseURL)
+	databaseToken := nsDatabaseToken
+	if databaseURL == "" && databaseToken == "" {
+		return "", "", nil
+	}
+	if databaseURL == "" {
+		return "", "", fmt.Errorf("--database-token requires --database-url")
+	}
+	if databaseToken == "" {
+		return "", "", fmt.Errorf("--database-url requires --database-token; an external database can't be used without its auth token")
+	}
+
+	u, err := url.Parse(databaseURL)
+	if err != nil || u.Host == "" {
+		return "", "", fmt.Errorf("invalid --database-url %q: expected e.g. libsql://<db>-<org>.turso.io", databaseURL)
+	}
+	switch u.Scheme {
+	case "libsql", "https", "wss":
+	case "http", "ws":
+		// The token would be sent in the clear
+		return "", "", fmt.Errorf("invalid --database-url %q: %s sends the database token unencrypted; use libsql, https or wss", databaseURL, u.Scheme)
+	default:
+		return "", "", fmt.Errorf("invalid --database-url %q: scheme must be libsql, https or wss", databaseURL)
+	}
+
+	if databaseToken == "-" {
+		if databaseToken, err = readTokenFromStdin(); err != nil {
+			return "", "", err
+		}
+		if databaseToken == "" {
+			return "", "", fmt.Errorf("no database token on stdin")
+		}
+	}
+
+	return databaseURL, databaseToken, nil
+}
+
+// maskToken hides all but the last four characters of a secret
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 8) + token[len(token)-4:]
+}
+
 func runNsInitialize(cmd *cobra.Command, args []string) error {
 	// Get API URL
 	apiURL := getAPIURL()
@@ -348,15 +931,17 @@ func runNsInitialize(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project and server
-	projectID := cfg.GetSelectedProject()
-	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+	client := api.NewClient(apiURL)
+
+	// Get selected project and server (or the --project/--server overrides)
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
 	}
 
-	serverID := cfg.GetSelectedServer()
-	if serverID == "" {
-		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
 	}
 
 	// Determine nameserver ID
@@ -365,78 +950,102 @@ func runNsInitialize(cmd *cobra.Command, args []string) error {
 		// Nameserver specified as argument
 		nameserverIdentifier := strings.Join(args, " ")
 		
-		// Get all nameservers to find the one specified
-		client := api.NewClient(apiURL)
-		databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
-		if err != nil {
-			return fmt.Errorf("failed to list nameservers: %w", err)
-		}
-
 		// Find nameserver by ID or name (case-insensitive)
-		var foundNameserver *api.Database
-		for i := range databasesResponse.Databases {
-			ns := &databasesResponse.Databases[i]
-			if ns.ID == nameserverIdentifier || 
-			   strings.EqualFold(ns.DatabaseName, nameserverIdentifier) {
-				foundNameserver = ns
-				break
-			}
-		}
-
-		if foundNameserver == nil {
-			return fmt.Errorf("nameserver '%s' not found. Use 'flux-relay ns list' to see available nameservers", nameserverIdentifier)
+		foundNameserver, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverIdentifier)
+		if err != nil {
+			return err
 		}
 		
 		nameserverID = foundNameserver.ID
 	} else {
-		// Use currently selected nameserver
-		nameserverID = cfg.GetSelectedNameserver()
+		// Use currently selected nameserver (or the --nameserver override)
+		nameserverID, err = currentNameserverID(cfg, client, accessToken, projectID, serverID)
+		if err != nil {
+			return err
+		}
 		if nameserverID == "" {
-			return fmt.Errorf("no nameserver selected. Use 'flux-relay ns <nameserver-name-or-id>' to select a nameserver, or specify one: flux-relay ns initialize <name>")
+			return fmt.Errorf("no nameserver selected. Use 'flux-relay ns use <nameserver-name-or-id>' to select a nameserver, or specify one: flux-relay ns initialize <name>")
 		}
 	}
 
-	// Validate schema type
-	validTypes := map[string]bool{
-		"messaging": true,
-		"analytics": true,
-		"both":      true,
+	// Without --type, use default_schema_type from the settings file
+	if !cmd.Flags().Changed("type") {
+		if configured := viper.GetString("default_schema_type"); configured != "" {
+			if !validSchemaTypes[configured] {
+				return fmt.Errorf("invalid default_schema_type '%s' in settings. Must be 'messaging', 'analytics', or 'both'", configured)
+			}
+			schemaType = configured
+		}
 	}
-	if !validTypes[schemaType] {
+
+	// Validate schema type
+	if !validSchemaTypes[schemaType] {
 		return fmt.Errorf("invalid schema type '%s'. Must be 'messaging', 'analytics', or 'both'", schemaType)
 	}
+	if schemaFile != "" && cmd.Flags().Changed("type") {
+		return fmt.Errorf("--type cannot be combined with --schema-file")
+	}
 
-	// Create API client and initialize nameserver
-	client := api.NewClient(apiURL)
-	
 	// Get nameserver name for display
+	var nameserverName string
 	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
 	if err == nil {
 		for _, ns := range databasesResponse.Databases {
 			if ns.ID == nameserverID {
+				nameserverName = ns.DatabaseName
 				fmt.Printf("Initializing schema for nameserver '%s' (%s)...\n", ns.DatabaseName, nameserverID)
 				if dropExisting {
-					fmt.Println("⚠️  WARNING: --drop-existing is enabled. Existing tables will be dropped!")
+					fmt.Println(ui.Warn("WARNING: --drop-existing is enabled. Existing tables will be dropped!"))
 				}
 				break
 			}
 		}
 	}
 
+	if schemaFile != "" {
+		if nameserverName == "" {
+			return fmt.Errorf("could not look up nameserver '%s' to determine its table suffix", nameserverID)
+		}
+		return applySchemaFile(client, accessToken, projectID, serverID, nameserverName, schemaFile)
+	}
+
 	// Call the API with schema type and drop existing flag
 	response, err := client.InitializeNameserverWithOptions(accessToken, projectID, serverID, nameserverID, schemaType, dropExisting)
-	if err != nil {
-		if apiErr, ok := err.(*api.APIError); ok {
-			if apiErr.Code() == "Unauthorized" || apiErr.Code() == "unauthorized" {
-				return fmt.Errorf("authentication failed. Please run 'flux-relay login' again")
+	for {
+		if err != nil {
+			if apiErr, ok := err.(*api.APIError); ok {
+				if api.IsUnauthorized(apiErr) {
+					return errSessionExpired
+				}
+				err = fmt.Errorf("API error: %w", apiErr)
+			} else {
+				err = fmt.Errorf("failed to initialize nameserver: %w", err)
 			}
-			return fmt.Errorf("API error: %w", apiErr)
+			// The API may have created some tables before failing
+			reportNameserverTables(client, accessToken, projectID, serverID, nameserverName)
+		} else if missing := missingTables(response); len(missing) > 0 {
+			reportPartialInitialize(response, missing)
+			err = fmt.Errorf("schema initialization incomplete: %d of %d table(s) missing", len(missing), len(response.AllTables))
+		} else {
+			break
 		}
-		return fmt.Errorf("failed to initialize nameserver: %w", err)
+
+		// Retrying never drops tables, so tables that were already created are
+		// kept. It's a question rather than a confirmation: --yes would retry a
+		// failure that keeps happening forever.
+		fmt.Println()
+		if !askYesNo("Retry initialization without --drop-existing?") {
+			fmt.Println("Re-run 'flux-relay ns initialize' (without --drop-existing) to create the missing tables.")
+			return err
+		}
+		fmt.Println("Retrying...")
+		response, err = client.InitializeNameserverWithOptions(accessToken, projectID, serverID, nameserverID, schemaType, false)
 	}
 
+	rememberSchemaType(cfg, nameserverID, schemaType, response)
+
 	fmt.Println()
-	fmt.Printf("✅ Schema initialized successfully!\n")
+	fmt.Println(ui.Success("Schema initialized successfully!"))
 	fmt.Printf("   Schema Type: %s\n", response.SchemaType)
 	if response.TablesCreated > 0 {
 		fmt.Printf("   Tables Created: %d\n", response.TablesCreated)
@@ -453,3 +1062,244 @@ func runNsInitialize(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// rememberSchemaType saves the schema type a nameserver was initialized with,
+// as reported by the API or else as requested, so the shell's help can match
+// its tables. It is only a hint, so failing to save it is ignored.
+func rememberSchemaType(cfg *config.ConfigManager, nameserverID, requested string, response *api.InitializeNameserverResponse) {
+	schemaType := response.SchemaType
+	if schemaType == "" {
+		schemaType = requested
+	}
+	if schemaType != "" {
+		cfg.SetSchemaType(nameserverID, schemaType)
+	}
+}
+
+// missingTables returns the tables the API reported as part of the schema
+// (AllTables) that it could not verify afterwards (VerifiedTables)
+func missingTables(response *api.InitializeNameserverResponse) []string {
+	if len(response.AllTables) == 0 || len(response.VerifiedTables) == 0 {
+		// Nothing to compare against, e.g. an API that doesn't verify tables
+		return nil
+	}
+	verified := make(map[string]bool, len(response.VerifiedTables))
+	for _, table := range response.VerifiedTables {
+		verified[strings.ToLower(table)] = true
+	}
+	var missing []string
+	for _, table := range response.AllTables {
+		if !verified[strings.ToLower(table)] {
+			missing = append(missing, table)
+		}
+	}
+	return missing
+}
+
+// reportPartialInitialize lists which tables of a partial initialization were
+// created and which were not
+func reportPartialInitialize(response *api.InitializeNameserverResponse, missing []string) {
+	fmt.Println()
+	fmt.Println(ui.Warn("Schema initialization only partially succeeded (%d of %d tables)",
+		len(response.AllTables)-len(missing), len(response.AllTables)))
+	if len(response.VerifiedTables) > 0 {
+		fmt.Println("   Created:")
+		for _, table := range response.VerifiedTables {
+			fmt.Println("     " + ui.Success("%s", table))
+		}
+	}
+	fmt.Println("   Missing:")
+	for _, table := range missing {
+		fmt.Println("     " + ui.Error("%s", table))
+	}
+	if response.Note != "" {
+		fmt.Printf("   Note: %s\n", response.Note)
+	}
+}
+
+// suffixedTableCondition returns a SQL condition on sqlite_master.name that
+// matches the tables of a nameserver, i.e. names ending in _<nameserver>
+func suffixedTableCondition(nameserverName string) string {
+	// Nameserver names are plain identifiers; escape '_' so LIKE matches it literally
+	pattern := "%\\_" + strings.ReplaceAll(nameserverName, "_", "\\_")
+	return fmt.Sprintf("name LIKE '%s' ESCAPE '\\'", pattern)
+}
+
+// reportNameserverTables prints the tables that currently exist with the
+// nameserver's suffix, so a failed initialization shows what it left behind
+func reportNameserverTables(client *api.Client, accessToken, projectID, serverID, nameserverName string) {
+	if nameserverName == "" {
+		return
+	}
+	query := "SELECT name FROM sqlite_master WHERE type = 'table' AND " + suffixedTableCondition(nameserverName) + " ORDER BY name"
+	response, err := client.ExecuteQuery(accessToken, projectID, serverID, query, []interface{}{})
+	if err != nil || !response.Success {
+		return
+	}
+
+	fmt.Println()
+	if len(response.Rows) == 0 {
+		fmt.Printf("No tables with suffix '_%s' exist yet.\n", nameserverName)
+		return
+	}
+	fmt.Printf("Tables with suffix '_%s' that exist now:\n", nameserverName)
+	for _, row := range response.Rows {
+		if len(row) > 0 {
+			fmt.Printf("  - %s\n", render.CellText(row[0]))
+		}
+	}
+}
+
+// applySchemaFile creates a nameserver's tables by executing the statements in
+// a local SQL file instead of calling the initialize endpoint
+func applySchemaFile(client *api.Client, accessToken, projectID, serverID, nameserverName, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	statements := splitSQLStatements(string(data))
+	if len(statements) == 0 {
+		return fmt.Errorf("schema file '%s' contains no SQL statements", path)
+	}
+
+	// Rewrite to the nameserver suffix and validate every table before running anything
+	suffix := "_" + nameserverName
+	var toRun []string
+	var tables []string
+	for i, stmt := range statements {
+		stmt = addNameserverSuffix(stmt, nameserverName)
+		tableName, isCreate, err := createTableName(stmt)
+		if err != nil {
+			return fmt.Errorf("schema file statement %d: %w", i+1, err)
+		}
+		if isCreate {
+			if !strings.HasSuffix(strings.ToLower(tableName), strings.ToLower(suffix)) {
+				return fmt.Errorf("schema file statement %d: table '%s' must end with the nameserver suffix '%s'", i+1, tableName, suffix)
+			}
+			tables = append(tables, tableName)
+			if dropExisting {
+				toRun = append(toRun, fmt.Sprintf("DROP TABLE IF EXISTS \"%s\"", tableName))
+			}
+		}
+		toRun = append(toRun, stmt)
+	}
+
+	fmt.Printf("Applying %d statement(s) from %s...\n", len(toRun), path)
+	for i, stmt := range toRun {
+		response, err := client.ExecuteQuery(accessToken, projectID, serverID, stmt, []interface{}{})
+		if err == nil && !response.Success {
+			err = fmt.Errorf("%s", response.ErrorMessage)
+		}
+		if err != nil {
+			return fmt.Errorf("statement %d of %d failed: %w\n   %s", i+1, len(toRun), err, stmt)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Success("Schema applied successfully!"))
+	fmt.Printf("   Schema File: %s\n", path)
+	fmt.Printf("   Statements Executed: %d\n", len(toRun))
+	if len(tables) > 0 {
+		fmt.Printf("   Tables Created: %d\n", len(tables))
+		for _, table := range tables {
+			fmt.Printf("     - %s\n", table)
+		}
+	}
+
+	return nil
+}
+
+// splitSQLStatements splits a SQL script on semicolons that are not inside
+// string literals, quoted identifiers, comments or the BEGIN ... END body of
+// a CREATE TRIGGER, whose statements end in semicolons of their own
+func splitSQLStatements(script string) []string {
+	var statements []string
+	start := -1
+	end := 0
+	var leading []string // the statement's first words, to spot CREATE TRIGGER
+	depth := 0           // open BEGIN and CASE blocks of a trigger
+	for _, tok := range scanSQLTokens(script) {
+		if tok.text == ";" && depth == 0 {
+			if start >= 0 {
+				statements = append(statements, script[start:end])
+			}
+			start = -1
+			leading = leading[:0]
+			continue
+		}
+		if start < 0 {
+			start = tok.start
+		}
+		end = tok.end
+
+		if len(leading) < 3 && tok.word {
+			leading = append(leading, strings.ToUpper(tok.text))
+		}
+		if !tok.word || !isCreateTrigger(leading) {
+			continue
+		}
+		switch strings.ToUpper(tok.text) {
+		case "BEGIN", "CASE":
+			depth++
+		case "END":
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	if start >= 0 {
+		statements = append(statements, script[start:end])
+	}
+	return statements
+}
+
+// isCreateTrigger reports whether a statement's first words are CREATE
+// [TEMP|TEMPORARY] TRIGGER
+func isCreateTrigger(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	if words[1] == "TEMP" || words[1] == "TEMPORARY" {
+		return len(words) > 2 && words[2] == "TRIGGER"
+	}
+	return words[1] == "TRIGGER"
+}
+
+// createTableName returns the table name created by a CREATE TABLE statement.
+// The second result is false for any other kind of statement.
+func createTableName(stmt string) (string, bool, error) {
+	tokens := scanSQLTokens(stmt)
+	i := 0
+	if i >= len(tokens) || !strings.EqualFold(tokens[i].text, "CREATE") {
+		return "", false, nil
+	}
+	i++
+	if i < len(tokens) && (strings.EqualFold(tokens[i].text, "TEMP") || strings.EqualFold(tokens[i].text, "TEMPORARY")) {
+		i++
+	}
+	if i >= len(tokens) || !strings.EqualFold(tokens[i].text, "TABLE") {
+		return "", false, nil
+	}
+	i++
+	for i < len(tokens) && tokens[i].word {
+		upper := strings.ToUpper(tokens[i].text)
+		if upper != "IF" && upper != "NOT" && upper != "EXISTS" {
+			break
+		}
+		i++
+	}
+	if i >= len(tokens) {
+		return "", true, fmt.Errorf("CREATE TABLE is missing a table name")
+	}
+	if i+1 < len(tokens) && tokens[i+1].text == "." {
+		return "", true, fmt.Errorf("schema-qualified table names are not supported: %s", tokens[i].text)
+	}
+
+	name := tokens[i].text
+	if !tokens[i].word && len(name) >= 2 {
+		// Quoted identifier
+		name = name[1 : len(name)-1]
+	}
+	return name, true, nil
+}
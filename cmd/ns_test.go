@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			"plain statements",
+			"SELECT 1; SELECT ';' -- ;\n; SELECT 2",
+			[]string{"SELECT 1", "SELECT ';'", "SELECT 2"},
+		},
+		{
+			"trigger body kept whole",
+			"CREATE TABLE a (id INTEGER);\n" +
+				"CREATE TRIGGER a_log AFTER INSERT ON a BEGIN\n  INSERT INTO log VALUES (new.id);\n  DELETE FROM log WHERE id < 0;\nEND;\n" +
+				"SELECT 1",
+			[]string{
+				"CREATE TABLE a (id INTEGER)",
+				"CREATE TRIGGER a_log AFTER INSERT ON a BEGIN\n  INSERT INTO log VALUES (new.id);\n  DELETE FROM log WHERE id < 0;\nEND",
+				"SELECT 1",
+			},
+		},
+		{
+			"CASE ... END inside a trigger",
+			"CREATE TEMP TRIGGER t BEFORE UPDATE ON a WHEN CASE WHEN new.id > 0 THEN 1 END BEGIN " +
+				"UPDATE a SET id = CASE WHEN id < 0 THEN 0 ELSE id END; END; SELECT 2",
+			[]string{
+				"CREATE TEMP TRIGGER t BEFORE UPDATE ON a WHEN CASE WHEN new.id > 0 THEN 1 END BEGIN " +
+					"UPDATE a SET id = CASE WHEN id < 0 THEN 0 ELSE id END; END",
+				"SELECT 2",
+			},
+		},
+		{
+			"END outside a trigger",
+			"SELECT CASE WHEN 1 THEN 2 END; SELECT 3",
+			[]string{"SELECT CASE WHEN 1 THEN 2 END", "SELECT 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSQLStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSQLStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalDatabaseFlagsSchemes(t *testing.T) {
+	t.Cleanup(func() { nsDatabaseURL, nsDatabaseToken = "", "" })
+	nsDatabaseToken = "secret-token"
+
+	for url, ok := range map[string]bool{
+		"libsql://db-org.turso.io": true,
+		"https://db-org.turso.io":  true,
+		"wss://db-org.turso.io":    true,
+		"http://db-org.turso.io":   false,
+		"ws://db-org.turso.io":     false,
+		"ftp://db-org.turso.io":    false,
+	} {
+		nsDatabaseURL = url
+		_, _, err := externalDatabaseFlags()
+		if ok && err != nil {
+			t.Errorf("%s: %v", url, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("%s: no error, want the scheme rejected", url)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var nsStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report table and row counts per nameserver",
+	Long: `Report, for every nameserver of the selected server (or --server), how
+many tables it has and how many rows they hold. With --all-servers every
+server of the project is included.
+
+Tables with a server_id column only count that server's rows, as in the
+shell's .stats; other tables are counted whole.
+
+--since counts only rows created within the given duration, using the
+created_at column of the built-in schemas, e.g. --since 168h for the last
+week. Tables without a created_at column are left out of those counts and
+listed separately.
+
+Use -o json for a report dashboards and scheduled jobs can read, or -o csv
+for one row per nameserver.
+
+Examples:
+  flux-relay ns stats
+  flux-relay ns stats --all-servers -o json
+  flux-relay ns stats --since 24h
+  flux-relay ns stats -o csv > stats.csv`,
+	Args: cobra.NoArgs,
+	RunE: runNsStats,
+}
+
+var (
+	nsStatsAllServers bool
+	nsStatsSince      time.Duration
+)
+
+func init() {
+	nsStatsCmd.Flags().BoolVar(&nsStatsAllServers, "all-servers", false, "Report on every server in the project")
+	nsStatsCmd.Flags().DurationVar(&nsStatsSince, "since", 0, "Count only rows created within this duration (e.g. 24h), by created_at")
+	setOutputFormats(nsStatsCmd, render.FormatJSON, render.FormatYAML, render.FormatCSV)
+	nsCmd.AddCommand(nsStatsCmd)
+}
+
+// nsStatsReport is the result of ns stats, as printed with -o json
+type nsStatsReport struct {
+	ProjectID   string          `json:"projectId"`
+	GeneratedAt string          `json:"generatedAt"`
+	Since       string          `json:"since,omitempty"` // rows created after this time, with --since
+	Servers     []nsStatsServer `json:"servers"`
+}
+
+type nsStatsServer struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Nameservers []nsStatsNameserver `json:"nameservers"`
+	Error       string              `json:"error,omitempty"`
+}
+
+type nsStatsNameserver struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsActive bool   `json:"isActive"`
+	Tables   int    `json:"tables"`
+	Rows     int64  `json:"rows"`
+	// NotCounted lists the tables left out with --since for lacking a
+	// created_at column
+	NotCounted []string `json:"notCounted,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// statsTable is a table counted by ns stats
+type statsTable struct {
+	server       int // index into the report's servers
+	nameserver   int // index into the server's nameservers
+	name         string
+	byServerID   bool
+	hasCreatedAt bool
+	rows         int64
+	err          error
+}
+
+func runNsStats(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	if nsStatsAllServers && serverOverride != "" {
+		return fmt.Errorf("--all-servers cannot be used with --server")
+	}
+	if nsStatsSince < 0 {
+		return fmt.Errorf("--since must be a positive duration, e.g. 24h")
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+	serverID := ""
+	if !nsStatsAllServers {
+		serverID, err = currentServerID(cfg, client, accessToken, projectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Don't show usage for failed counts; the report explains them
+	cmd.SilenceUsage = true
+
+	serversResponse, err := client.ListServers(accessToken, projectID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return errSessionExpired
+		}
+		return fmt.Errorf("failed to list servers: %w", err)
+	}
+
+	now := time.Now()
+	report := nsStatsReport{ProjectID: projectID, GeneratedAt: now.UTC().Format(time.RFC3339), Servers: []nsStatsServer{}}
+	if nsStatsSince > 0 {
+		report.Since = now.Add(-nsStatsSince).UTC().Format(time.RFC3339)
+	}
+	for _, server := range serversResponse.Servers {
+		if serverID == "" || server.ID == serverID {
+			report.Servers = append(report.Servers, nsStatsServer{ID: server.ID, Name: server.Name, Nameservers: []nsStatsNameserver{}})
+		}
+	}
+
+	// List each server's nameservers and their tables in parallel
+	tablesByServer := make([][]statsTable, len(report.Servers))
+	errs := make([]error, len(report.Servers))
+	var wg sync.WaitGroup
+	for i := range report.Servers {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			tablesByServer[idx], errs[idx] = statsServerTables(client, accessToken, projectID, &report.Servers[idx], idx)
+		}(i)
+	}
+	wg.Wait()
+
+	var tables []statsTable
+	for i, serverTables := range tablesByServer {
+		if errs[i] == errSessionExpired {
+			return errs[i]
+		}
+		if errs[i] != nil {
+			report.Servers[i].Error = errs[i].Error()
+		}
+		tables = append(tables, serverTables...)
+	}
+
+	// Count the tables in parallel with a bounded number of workers
+	jobs := make(chan int)
+	for w := 0; w < statsWorkers && w < len(tables); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				table := &tables[i]
+				table.rows, table.err = countTableRows(client, accessToken, projectID, report.Servers[table.server].ID,
+					table.name, table.byServerID, nsStatsSince)
+			}
+		}()
+	}
+	for i := range tables {
+		if nsStatsSince > 0 && !tables[i].hasCreatedAt {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, table := range tables {
+		nameserver := &report.Servers[table.server].Nameservers[table.nameserver]
+		nameserver.Tables++
+		switch {
+		case nsStatsSince > 0 && !table.hasCreatedAt:
+			nameserver.NotCounted = append(nameserver.NotCounted, table.name)
+		case table.err != nil:
+			if api.IsUnauthorized(table.err) {
+				return errSessionExpired
+			}
+			if nameserver.Error == "" {
+				nameserver.Error = fmt.Sprintf("counting %s failed: %v", table.name, table.err)
+				failed++
+			}
+		default:
+			nameserver.Rows += table.rows
+		}
+	}
+	for _, server := range report.Servers {
+		if server.Error != "" {
+			failed++
+		}
+	}
+
+	switch {
+	case structuredOutput():
+		if err := printStructured(os.Stdout, report, "report"); err != nil {
+			return err
+		}
+	case outputFormat == render.FormatCSV:
+		printNsStatsCSV(report)
+	default:
+		printNsStats(report)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d nameserver(s) or server(s) could not be counted", failed)
+	}
+	return nil
+}
+
+// statsServerTables fills in a server's nameservers and returns the tables
+// of each that ns stats counts
+func statsServerTables(client *api.Client, accessToken, projectID string, server *nsStatsServer, serverIdx int) ([]statsTable, error) {
+	databasesResponse, err := client.ListDatabases(accessToken, projectID, server.ID)
+	if err != nil {
+		return nil, checkSession(err)
+	}
+	nameservers := make(map[string]bool, len(databasesResponse.Databases))
+	index := make(map[string]int, len(databasesResponse.Databases))
+	for _, db := range databasesResponse.Databases {
+		nameservers[db.DatabaseName] = db.IsActive
+		index[db.DatabaseName] = len(server.Nameservers)
+		server.Nameservers = append(server.Nameservers, nsStatsNameserver{ID: db.ID, Name: db.DatabaseName, IsActive: db.IsActive})
+	}
+	if len(nameservers) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT m.name, " +
+		"EXISTS (SELECT 1 FROM pragma_table_info(m.name) WHERE name = 'server_id') AS has_server_id, " +
+		"EXISTS (SELECT 1 FROM pragma_table_info(m.name) WHERE name = 'created_at') AS has_created_at " +
+		"FROM sqlite_master AS m WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY m.name"
+	queryResponse, err := executeSqlQuery(client, accessToken, projectID, server.ID, query, nil)
+	if err != nil {
+		if err == errSessionExpired {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	var tables []statsTable
+	for _, row := range queryResponse.Rows {
+		if len(row) < 3 || row[0] == nil {
+			continue
+		}
+		name := render.CellText(row[0])
+		nameserver, _, ok := tableNameserver(name, nameservers)
+		if !ok {
+			continue
+		}
+		tables = append(tables, statsTable{
+			server:       serverIdx,
+			nameserver:   index[nameserver],
+			name:         name,
+			byServerID:   render.CellText(row[1]) == "1",
+			hasCreatedAt: render.CellText(row[2]) == "1",
+		})
+	}
+	return tables, nil
+}
+
+// countTableRows returns the number of rows in a table, limited to the
+// server's rows when byServerID is set and to rows created within since when
+// it isn't zero
+func countTableRows(client *api.Client, accessToken, projectID, serverID, tableName string, byServerID bool, since time.Duration) (int64, error) {
+	var conditions []string
+	if byServerID {
+		conditions = append(conditions, "server_id = ?")
+	}
+	if since > 0 {
+		conditions = append(conditions, fmt.Sprintf("created_at >= datetime('now', '-%d seconds')", int64(since.Seconds())))
+	}
+	query := "SELECT COUNT(*) FROM " + quoteIdentifier(tableName)
+	for i, condition := range conditions {
+		if i == 0 {
+			query += " WHERE " + condition
+		} else {
+			query += " AND " + condition
+		}
+	}
+
+	queryResponse, err := client.ExecuteQuery(accessToken, projectID, serverID, query, serverIDArgs(query, serverID))
+	if err != nil {
+		return 0, err
+	}
+	if !queryResponse.Success {
+		return 0, fmt.Errorf("%s", queryResponse.ErrorMessage)
+	}
+	if len(queryResponse.Rows) != 1 || len(queryResponse.Rows[0]) != 1 {
+		return 0, fmt.Errorf("unexpected result")
+	}
+	count, ok := queryResponse.Rows[0][0].(float64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count %v", queryResponse.Rows[0][0])
+	}
+	return int64(count), nil
+}
+
+// printNsStats prints the ns stats report as a table
+func printNsStats(report nsStatsReport) {
+	if len(report.Servers) == 0 {
+		fmt.Println("No servers found in this project.")
+		return
+	}
+
+	rowsHeader := "ROWS"
+	if report.Since != "" {
+		rowsHeader = "NEW ROWS"
+		fmt.Printf("Rows created since %s\n\n", report.Since)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "SERVER\tNAMESERVER\tSTATUS\tTABLES\t%s\n", rowsHeader)
+	fmt.Fprintln(w, "──\t──\t──\t──\t──")
+	var notCounted []string
+	var totalTables int
+	var totalRows int64
+	for _, server := range report.Servers {
+		if server.Error != "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\terror: %s\n", server.Name, server.Error)
+			continue
+		}
+		if len(server.Nameservers) == 0 {
+			fmt.Fprintf(w, "%s\t-\t-\t0\t0\n", server.Name)
+			continue
+		}
+		for _, nameserver := range server.Nameservers {
+			status := "Active"
+			if !nameserver.IsActive {
+				status = "Inactive"
+			}
+			rows := fmt.Sprint(nameserver.Rows)
+			if nameserver.Error != "" {
+				rows = "error: " + nameserver.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", server.Name, nameserver.Name, status, nameserver.Tables, rows)
+			totalTables += nameserver.Tables
+			totalRows += nameserver.Rows
+			notCounted = append(notCounted, nameserver.NotCounted...)
+		}
+	}
+	fmt.Fprintln(w, "\t\t\t──\t──")
+	fmt.Fprintf(w, "TOTAL\t\t\t%d\t%d\n", totalTables, totalRows)
+	w.Flush()
+
+	if len(notCounted) > 0 {
+		sort.Strings(notCounted)
+		fmt.Println()
+		fmt.Printf("Not counted (no created_at column): %s\n", strings.Join(notCounted, ", "))
+	}
+}
+
+// printNsStatsCSV prints the ns stats report as CSV, one row per nameserver.
+// A server that couldn't be listed gets one row with only its error, and
+// rows is empty for a nameserver that couldn't be counted.
+func printNsStatsCSV(report nsStatsReport) {
+	fmt.Println("serverId,server,nameserverId,nameserver,isActive,tables,rows,notCounted,error")
+	for _, server := range report.Servers {
+		if server.Error != "" {
+			fmt.Printf("%s,%s,,,,,,,%s\n", render.CSVField(server.ID, ""), render.CSVField(server.Name, ""),
+				render.CSVField(server.Error, ""))
+			continue
+		}
+		for _, nameserver := range server.Nameservers {
+			rows := ""
+			if nameserver.Error == "" {
+				rows = fmt.Sprint(nameserver.Rows)
+			}
+			fmt.Printf("%s,%s,%s,%s,%t,%d,%s,%s,%s\n", render.CSVField(server.ID, ""), render.CSVField(server.Name, ""),
+				render.CSVField(nameserver.ID, ""), render.CSVField(nameserver.Name, ""), nameserver.IsActive,
+				nameserver.Tables, rows, render.CSVField(strings.Join(nameserver.NotCounted, " "), ""),
+				render.CSVField(nameserver.Error, ""))
+		}
+	}
+}
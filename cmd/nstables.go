@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var nsTablesCmd = &cobra.Command{
+	Use:   "tables [nameserver-name-or-id]",
+	Short: "List a nameserver's tables",
+	Long: `List the tables of a nameserver, i.e. the tables carrying its suffix, like
+the shell's .tables but without opening the shell.
+
+If no nameserver is specified, the currently selected nameserver is used.
+
+--counts adds each table's row count. Tables with a server_id column only
+count the current server's rows, as in 'ns stats'; other tables are counted
+whole.
+
+Examples:
+  flux-relay ns tables
+  flux-relay ns tables db --counts
+  flux-relay ns tables -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNsTables,
+}
+
+var nsTablesCounts bool
+
+func init() {
+	nsTablesCmd.Flags().BoolVar(&nsTablesCounts, "counts", false, "Add each table's row count")
+	setOutputFormats(nsTablesCmd, render.FormatJSON, render.FormatYAML, render.FormatCSV)
+	nsCmd.AddCommand(nsTablesCmd)
+}
+
+// nsTable is a table listed by ns tables, as printed with -o json
+type nsTable struct {
+	Name string `json:"name"`
+	// Rows is only set with --counts
+	Rows  *int64 `json:"rows,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func runNsTables(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+
+	// The argument takes precedence over --nameserver and the saved selection
+	if len(args) == 1 {
+		nameserverOverride = args[0]
+	}
+	nameserverID, err := currentNameserverID(cfg, client, accessToken, projectID, serverID)
+	if err != nil {
+		return err
+	}
+	if nameserverID == "" {
+		return fmt.Errorf("no nameserver selected. Use 'flux-relay ns use <nameserver-name-or-id>' or pass a nameserver name")
+	}
+	ns, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverID)
+	if err != nil {
+		return err
+	}
+
+	// Don't show usage for failed counts; the listing explains them
+	cmd.SilenceUsage = true
+
+	suffixed, err := nameserverTables(client, accessToken, projectID, serverID, ns.DatabaseName)
+	if err != nil {
+		return err
+	}
+	tables := make([]nsTable, len(suffixed))
+	for i, table := range suffixed {
+		tables[i] = nsTable{Name: table.name}
+	}
+
+	failed := 0
+	if nsTablesCounts {
+		// Count the tables in parallel with a bounded number of workers
+		errs := make([]error, len(tables))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < statsWorkers && w < len(tables); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					rows, err := countTableRows(client, accessToken, projectID, serverID, suffixed[i].name, suffixed[i].byServerID, 0)
+					if err != nil {
+						errs[i] = err
+						continue
+					}
+					tables[i].Rows = &rows
+				}
+			}()
+		}
+		for i := range tables {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		for i, err := range errs {
+			if api.IsUnauthorized(err) {
+				return errSessionExpired
+			}
+			if err != nil {
+				tables[i].Error = err.Error()
+				failed++
+			}
+		}
+	}
+
+	switch {
+	case structuredOutput():
+		if err := printStructured(os.Stdout, tables, "tables"); err != nil {
+			return err
+		}
+	case outputFormat == render.FormatCSV:
+		printNsTablesCSV(tables)
+	default:
+		printNsTables(ns.DatabaseName, tables)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d table(s) could not be counted", failed, len(tables))
+	}
+	return nil
+}
+
+// printNsTables prints the tables of ns tables, with their row counts if
+// they were counted
+func printNsTables(nameserverName string, tables []nsTable) {
+	if len(tables) == 0 {
+		fmt.Printf("Nameserver '%s' has no tables.\n", nameserverName)
+		fmt.Println()
+		fmt.Println("Initialize its schema with: flux-relay ns initialize", nameserverName)
+		return
+	}
+
+	fmt.Printf("Found %d table(s) in nameserver '%s':\n\n", len(tables), nameserverName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	if nsTablesCounts {
+		fmt.Fprintln(w, "TABLE\tROWS")
+		fmt.Fprintln(w, "─────\t────")
+	} else {
+		fmt.Fprintln(w, "TABLE")
+		fmt.Fprintln(w, "─────")
+	}
+	for _, table := range tables {
+		switch {
+		case !nsTablesCounts:
+			fmt.Fprintln(w, table.Name)
+		case table.Error != "":
+			fmt.Fprintf(w, "%s\terror: %s\n", table.Name, table.Error)
+		default:
+			fmt.Fprintf(w, "%s\t%d\n", table.Name, *table.Rows)
+		}
+	}
+	w.Flush()
+}
+
+// printNsTablesCSV prints the tables of ns tables as CSV, with an empty
+// rows field for a table that couldn't be counted
+func printNsTablesCSV(tables []nsTable) {
+	fields := []string{"table"}
+	if nsTablesCounts {
+		fields = append(fields, "rows")
+	}
+	fmt.Println(strings.Join(fields, ","))
+	for _, table := range tables {
+		fields = []string{render.CSVField(table.Name, "")}
+		if nsTablesCounts {
+			rows := ""
+			if table.Rows != nil {
+				rows = fmt.Sprint(*table.Rows)
+			}
+			fields = append(fields, rows)
+		}
+		fmt.Println(strings.Join(fields, ","))
+	}
+}
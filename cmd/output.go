@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/spf13/cobra"
+)
+
+// Query results are rendered by the render package; these are the settings
+// the sql command and the shell pass to it.
+
+// Markers for NULL and empty-string cells, so the two are never confused
+var (
+	nullString  = "NULL"
+	emptyString = "''"
+)
+
+// formatNumbers adds thousands separators to numeric columns in table output
+// (sql --format-numbers, .numfmt in the shell). CSV and JSON are never changed.
+var formatNumbers bool
+
+// noHeader leaves the header out of table and CSV output (sql --no-header,
+// .headers off in the shell)
+var noHeader bool
+
+// displayTimeZone converts timestamps in table output to a zone (sql --tz,
+// .tz in the shell). Nil leaves them as stored.
+var displayTimeZone *time.Location
+
+// renderOptions returns the render settings for the current output flags
+func renderOptions() render.RenderOptions {
+	return render.RenderOptions{
+		Format:        outputFormat,
+		NullString:    nullString,
+		EmptyString:   emptyString,
+		ShowTypes:     sqlShowTypes,
+		FormatNumbers: formatNumbers,
+		TimeZone:      displayTimeZone,
+		Quiet:         sqlQuiet,
+		JSONObjects:   sqlJSONObjects,
+		NoHeader:      noHeader,
+	}
+}
+
+// outputFormatsAnnotation is the cobra annotation listing the --output
+// formats a command can print, comma-separated. Commands without it only
+// print tables.
+const outputFormatsAnnotation = "output-formats"
+
+// setOutputFormats declares the --output formats cmd can print besides table
+func setOutputFormats(cmd *cobra.Command, formats ...string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[outputFormatsAnnotation] = strings.Join(append([]string{render.FormatTable}, formats...), ",")
+}
+
+// checkOutputFormat rejects an --output format cmd can't print, rather than
+// silently printing a table instead
+func checkOutputFormat(cmd *cobra.Command) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	supported := []string{render.FormatTable}
+	if formats, ok := cmd.Annotations[outputFormatsAnnotation]; ok {
+		supported = strings.Split(formats, ",")
+	}
+	for _, format := range supported {
+		if format == outputFormat {
+			return nil
+		}
+	}
+	if len(supported) == 1 {
+		return fmt.Errorf("'%s' only prints tables; --output %s is not supported", cmd.CommandPath(), outputFormat)
+	}
+	last := len(supported) - 1
+	return fmt.Errorf("'%s' can't print %s. Use --output %s or %s", cmd.CommandPath(), outputFormat,
+		strings.Join(supported[:last], ", "), supported[last])
+}
+
+// structuredOutput reports whether --output asks for data (JSON or YAML)
+// rather than text
+func structuredOutput() bool {
+	return outputFormat == render.FormatJSON || outputFormat == render.FormatYAML
+}
+
+// printStructured writes v to out as JSON or YAML, whichever --output asks
+// for. what names v in the error, e.g. "report".
+func printStructured(out io.Writer, v interface{}, what string) error {
+	if outputFormat == render.FormatYAML {
+		data, err := render.MarshalYAML(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s as YAML: %w", what, err)
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s as JSON: %w", what, err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
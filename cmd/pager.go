@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// defaultPager is used when $PAGER is not set. -F quits at once if the output
+// fits on one screen, -R keeps colors and -X leaves the output on screen.
+const defaultPager = "less -FRX"
+
+// noPager disables paging for the sql command (--no-pager)
+var noPager bool
+
+// pagerCommand returns the pager to run, from $PAGER or defaultPager. An
+// empty $PAGER disables paging.
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = defaultPager
+	}
+	return strings.Fields(pager)
+}
+
+// terminalHeight returns the number of lines on screen, from $LINES when the
+// shell exports it, or a conservative default
+func terminalHeight() int {
+	if lines, err := strconv.Atoi(os.Getenv("LINES")); err == nil && lines > 0 {
+		return lines
+	}
+	return 24
+}
+
+// showPaged writes rendered output to stdout. When enabled, stdout is a
+// terminal and the output is taller than the screen, it goes through the
+// pager instead; if the pager can't be started the output is printed as is.
+func showPaged(output string, enabled bool) {
+	if !enabled || !isTerminal(os.Stdout) || strings.Count(output, "\n") < terminalHeight() {
+		fmt.Print(output)
+		return
+	}
+
+	args := pagerCommand()
+	if len(args) == 0 {
+		fmt.Print(output)
+		return
+	}
+
+	pager := exec.Command(args[0], args[1:]...)
+	pager.Stdin = strings.NewReader(output)
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	if err := pager.Start(); err != nil {
+		fmt.Print(output)
+		return
+	}
+	pager.Wait()
+}
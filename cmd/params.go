@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// queryPlaceholder is a bind parameter found in a query: either a positional
+// "?" or a named ":name"
+type queryPlaceholder struct {
+	name  string // empty for "?"
+	start int
+	end   int
+}
+
+// findPlaceholders returns the bind parameters of query in order, ignoring
+// anything inside string literals, quoted identifiers and comments
+func findPlaceholders(query string) ([]queryPlaceholder, error) {
+	tokens := scanSQLTokens(query)
+	var placeholders []queryPlaceholder
+	for i, tok := range tokens {
+		var next *sqlToken
+		if i+1 < len(tokens) && tokens[i+1].start == tok.end && tokens[i+1].word {
+			next = &tokens[i+1]
+		}
+		switch tok.text {
+		case "?":
+			if next != nil {
+				return nil, fmt.Errorf("numbered placeholder '?%s' is not supported with --params; use '?' or ':name'", next.text)
+			}
+			placeholders = append(placeholders, queryPlaceholder{start: tok.start, end: tok.end})
+		case ":":
+			if next != nil {
+				placeholders = append(placeholders, queryPlaceholder{name: next.text, start: tok.start, end: next.end})
+			}
+		}
+	}
+	return placeholders, nil
+}
+
+// placeholderCountError explains a mismatch between a query's '?'
+// placeholders and the values of a params file array. The server ID is only
+// bound for you when no params are given, so a missing server_id value is the
+// usual cause of having one value too few.
+func placeholderCountError(query string, placeholders, values int) error {
+	err := fmt.Errorf("query has %d '?' placeholder(s) but %d value(s) were provided in the params file", placeholders, values)
+	if values < placeholders && strings.Contains(strings.ToLower(query), "server_id") {
+		return fmt.Errorf("%w\n💡 With --params the server ID isn't added for you; include it in the array for 'server_id = ?'", err)
+	}
+	return err
+}
+
+// loadQueryParams binds query arguments from a JSON params file, --set
+// values, or both. An array file is bound positionally to "?" placeholders;
+// an object file and --set values are bound to ":name" placeholders, which
+// are rewritten to "?" with the args ordered to match. --set values override
+// the file's and are bound as text.
+func loadQueryParams(path string, sets []string, query string) (string, []interface{}, error) {
+	placeholders, err := findPlaceholders(query)
+	if err != nil {
+		return "", nil, err
+	}
+	named := 0
+	for _, p := range placeholders {
+		if p.name != "" {
+			named++
+		}
+	}
+
+	values := map[string]interface{}{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read params file: %w", err)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber() // keep large integers exact
+
+		trimmed := bytes.TrimSpace(data)
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("[")):
+			var args []interface{}
+			if err := decoder.Decode(&args); err != nil {
+				return "", nil, fmt.Errorf("invalid params file %s: %w", path, err)
+			}
+			if len(sets) > 0 {
+				return "", nil, fmt.Errorf("--set needs ':name' placeholders and can't be combined with an array params file")
+			}
+			if named > 0 {
+				return "", nil, fmt.Errorf("query uses named placeholders (:name); the params file must be a JSON object, not an array")
+			}
+			if len(args) != len(placeholders) {
+				return "", nil, placeholderCountError(query, len(placeholders), len(args))
+			}
+			return query, args, nil
+
+		case bytes.HasPrefix(trimmed, []byte("{")):
+			if err := decoder.Decode(&values); err != nil {
+				return "", nil, fmt.Errorf("invalid params file %s: %w", path, err)
+			}
+
+		default:
+			return "", nil, fmt.Errorf("invalid params file %s: expected a JSON array or object", path)
+		}
+	}
+
+	for _, set := range sets {
+		name, value, ok := strings.Cut(set, "=")
+		name = strings.TrimPrefix(strings.TrimSpace(name), ":")
+		if !ok || name == "" {
+			return "", nil, fmt.Errorf("invalid --set '%s': expected name=value", set)
+		}
+		values[name] = value
+	}
+
+	return bindNamedParams(query, placeholders, named, values)
+}
+
+// bindNamedParams rewrites the ":name" placeholders of query to "?" and
+// returns the matching args from values
+func bindNamedParams(query string, placeholders []queryPlaceholder, named int, values map[string]interface{}) (string, []interface{}, error) {
+	if named != len(placeholders) {
+		return "", nil, fmt.Errorf("query mixes '?' and ':name' placeholders; use only ':name' with --set or a JSON object params file")
+	}
+
+	var rewritten strings.Builder
+	args := make([]interface{}, 0, len(placeholders))
+	used := make(map[string]bool)
+	last := 0
+	for _, p := range placeholders {
+		value, ok := values[p.name]
+		if !ok {
+			return "", nil, fmt.Errorf("no value for placeholder ':%s'; add it to the params file or pass --set %s=...", p.name, p.name)
+		}
+		used[p.name] = true
+		args = append(args, value)
+		rewritten.WriteString(query[last:p.start])
+		rewritten.WriteString("?")
+		last = p.end
+	}
+	rewritten.WriteString(query[last:])
+
+	for name := range values {
+		if !used[name] {
+			return "", nil, fmt.Errorf("value '%s' does not match any ':%s' placeholder in the query", name, name)
+		}
+	}
+	return rewritten.String(), args, nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check API connectivity and token validity",
+	Long: `Check that the Flux Relay API is reachable and your token is valid.
+
+Times an authenticated request for the current user and reports the
+round-trip latency, HTTP status and when the stored token expires.
+Exits with a non-zero status if the API is unreachable or the token is rejected.
+
+Examples:
+  flux-relay ping
+  flux-relay ping -o json`,
+	Args: cobra.NoArgs,
+	RunE: runPing,
+}
+
+func init() {
+	setOutputFormats(pingCmd, render.FormatJSON, render.FormatYAML)
+	rootCmd.AddCommand(pingCmd)
+}
+
+// pingResult is the --output json form of a ping
+type pingResult struct {
+	Reachable      bool   `json:"reachable"`
+	LatencyMs      int64  `json:"latencyMs"`
+	HTTPStatus     int    `json:"httpStatus,omitempty"`
+	Authenticated  bool   `json:"authenticated"`
+	TokenExpiresAt string `json:"tokenExpiresAt,omitempty"`
+}
+
+func runPing(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Use the stored token even if it has expired locally, so the API gets
+	// the final say on whether it is still accepted
+	cfg := config.New()
+	stored, err := cfg.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	accessToken := ""
+	var expiresAt time.Time
+	if stored != nil {
+		accessToken = stored.AccessToken
+		expiresAt = stored.ExpiresAt
+	}
+
+	client := api.NewClient(apiURL)
+	start := time.Now()
+	userInfo, reqErr := client.GetCurrentUser(accessToken)
+	latency := time.Since(start)
+
+	result := pingResult{
+		LatencyMs: latency.Milliseconds(),
+	}
+	if !expiresAt.IsZero() {
+		result.TokenExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+
+	var apiErr *api.APIError
+	switch {
+	case reqErr == nil:
+		result.Reachable = true
+		result.Authenticated = true
+		result.HTTPStatus = http.StatusOK
+	case errors.As(reqErr, &apiErr):
+		// The API answered, it just refused the token
+		result.Reachable = true
+		result.HTTPStatus = apiErr.StatusCode
+	}
+
+	// Don't show usage for a failed check; the report already explains it
+	cmd.SilenceUsage = true
+
+	if structuredOutput() {
+		if err := printStructured(os.Stdout, result, "result"); err != nil {
+			return err
+		}
+	} else {
+		if !result.Reachable {
+			fmt.Println(ui.Error("API unreachable: %s", apiURL))
+			fmt.Printf("   %v\n", reqErr)
+		} else {
+			fmt.Println(ui.Success("API reachable: %s", apiURL))
+			fmt.Printf("   Latency: %dms (HTTP %d)\n", result.LatencyMs, result.HTTPStatus)
+			if result.Authenticated {
+				fmt.Println(ui.Success("Authenticated as %s", userInfo.Email()))
+			} else if accessToken == "" {
+				fmt.Println(ui.Warn("Not logged in. Run 'flux-relay login' first"))
+			} else {
+				fmt.Println(ui.Error("Token rejected: %v", reqErr))
+			}
+		}
+
+		switch {
+		case expiresAt.IsZero():
+		case time.Now().After(expiresAt):
+			fmt.Println(ui.Warn("Token expired at %s. Run 'flux-relay login' again", expiresAt.Local().Format("2006-01-02 15:04:05")))
+		default:
+			fmt.Printf("   Token expires: %s (in %s)\n", expiresAt.Local().Format("2006-01-02 15:04:05"), time.Until(expiresAt).Round(time.Minute))
+		}
+	}
+
+	if !result.Reachable {
+		return fmt.Errorf("API is not reachable")
+	}
+	if !result.Authenticated {
+		return fmt.Errorf("not authenticated")
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -33,12 +34,52 @@ var prListCmd = &cobra.Command{
 	RunE:  runPrList,
 }
 
+var prRenameCmd = &cobra.Command{
+	Use:   "rename <project-name-or-id>",
+	Short: "Rename a project",
+	Long: `Change the name of a project. The project keeps its ID, so the saved
+selection and anything using the ID are not affected.
+
+Examples:
+  flux-relay pr rename MyProject --name Chat
+  flux-relay pr rename 56OSXXQH --name "Chat (staging)"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPrRename,
+}
+
+var prDeleteCmd = &cobra.Command{
+	Use:   "delete <project-name-or-id>",
+	Short: "Delete a project and everything in it",
+	Long: `Delete a project. This also deletes all of its servers and their
+nameservers, and cannot be undone.
+
+You are asked to confirm unless --yes is given; without a terminal to ask
+on, --yes is required. If the deleted project was selected, the selection
+is cleared.
+
+Examples:
+  flux-relay pr delete OldProject
+  flux-relay pr delete 56OSXXQH --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPrDelete,
+}
+
+var prRenameName string
+
 func init() {
+	prRenameCmd.Flags().StringVar(&prRenameName, "name", "", "New project name")
+	prRenameCmd.MarkFlagRequired("name")
+	prCmd.AddCommand(prRenameCmd)
+	prCmd.AddCommand(prDeleteCmd)
 	prCmd.AddCommand(prListCmd)
 	rootCmd.AddCommand(prCmd)
 }
 
 func runPrShowOrSelect(cmd *cobra.Command, args []string) error {
+	if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+		return err
+	}
+
 	// Get API URL
 	apiURL := getAPIURL()
 
@@ -80,7 +121,7 @@ func runPrShowOrSelect(cmd *cobra.Command, args []string) error {
 		}
 
 		if selectedProject == nil {
-			fmt.Printf("⚠️  Selected project (ID: %s) not found.\n", selectedProjectID)
+			fmt.Println(ui.Warn("Selected project (ID: %s) not found.", selectedProjectID))
 			fmt.Println("Please select a different project.")
 			return nil
 		}
@@ -96,26 +137,11 @@ func runPrShowOrSelect(cmd *cobra.Command, args []string) error {
 	// Join all args to handle names with spaces (e.g., "My Project Name")
 	projectIdentifier := strings.Join(args, " ")
 
-	// Get all projects
+	// Find project by ID or name (case-insensitive)
 	client := api.NewClient(apiURL)
-	projectsResponse, err := client.ListProjects(accessToken)
+	selectedProject, err := resolveProject(client, accessToken, projectIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to list projects: %w", err)
-	}
-
-	// Find project by ID or name (case-insensitive)
-	var selectedProject *api.Project
-	for i := range projectsResponse.Projects {
-		project := &projectsResponse.Projects[i]
-		if project.ID == projectIdentifier || 
-		   strings.EqualFold(project.Name, projectIdentifier) {
-			selectedProject = project
-			break
-		}
-	}
-
-	if selectedProject == nil {
-		return fmt.Errorf("project '%s' not found. Use 'flux-relay pr list' to see available projects", projectIdentifier)
+		return err
 	}
 
 	// Save selected project
@@ -123,7 +149,7 @@ func runPrShowOrSelect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save project selection: %w", err)
 	}
 
-	fmt.Printf("✅ Selected project: %s (%s)\n", selectedProject.Name, selectedProject.ID)
+	fmt.Println(ui.Success("Selected project: %s (%s)", selectedProject.Name, selectedProject.ID))
 	if selectedProject.Description != "" {
 		fmt.Printf("   Description: %s\n", selectedProject.Description)
 	}
@@ -134,6 +160,8 @@ func runPrShowOrSelect(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runPrList lists the projects in the account. It backs both 'pr list' and
+// 'projects list'.
 func runPrList(cmd *cobra.Command, args []string) error {
 	// Get API URL
 	apiURL := getAPIURL()
@@ -150,8 +178,8 @@ func runPrList(cmd *cobra.Command, args []string) error {
 	projectsResponse, err := client.ListProjects(accessToken)
 	if err != nil {
 		if apiErr, ok := err.(*api.APIError); ok {
-			if apiErr.Code() == "Unauthorized" || apiErr.Code() == "unauthorized" {
-				return fmt.Errorf("authentication failed. Please run 'flux-relay login' again")
+			if api.IsUnauthorized(apiErr) {
+				return errSessionExpired
 			}
 			return fmt.Errorf("API error: %w", apiErr)
 		}
@@ -211,3 +239,103 @@ func runPrList(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runPrRename(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(prRenameName) == "" {
+		return fmt.Errorf("project name cannot be empty")
+	}
+	if len(prRenameName) > 100 {
+		return fmt.Errorf("project name must be 1-100 characters")
+	}
+	if strings.ContainsAny(prRenameName, "\r\n\t") {
+		return fmt.Errorf("project name cannot contain tabs or line breaks")
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+	project, err := resolveProject(client, accessToken, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Names select projects, so they must stay unique
+	if !strings.EqualFold(prRenameName, project.Name) {
+		projectsResponse, err := client.ListProjects(accessToken)
+		if err != nil {
+			return checkSession(fmt.Errorf("failed to list projects: %w", err))
+		}
+		for _, other := range projectsResponse.Projects {
+			if other.ID != project.ID && strings.EqualFold(other.Name, prRenameName) {
+				return fmt.Errorf("a project named '%s' already exists (%s)", other.Name, other.ID)
+			}
+		}
+	}
+
+	updated, err := client.RenameProject(accessToken, project.ID, prRenameName)
+	if err != nil {
+		return checkSession(fmt.Errorf("failed to rename project: %w", err))
+	}
+	if updated.Name == "" {
+		updated.Name = prRenameName
+	}
+
+	fmt.Println(ui.Success("Renamed project %s: %s → %s", project.ID, project.Name, updated.Name))
+	return nil
+}
+
+func runPrDelete(cmd *cobra.Command, args []string) error {
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+	project, err := resolveProject(client, accessToken, args[0])
+	if err != nil {
+		return err
+	}
+
+	if !assumingYes() {
+		fmt.Printf("This will permanently delete project %s (%s)", project.Name, project.ID)
+		if serversResponse, err := client.ListServers(accessToken, project.ID); err == nil {
+			fmt.Printf(" and its %d server(s) with all their nameservers", len(serversResponse.Servers))
+		} else {
+			fmt.Print(" with all its servers and nameservers")
+		}
+		fmt.Println(".")
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to delete project %s without confirmation. Pass --yes to confirm", project.Name)
+		}
+		if !confirm("Delete it?") {
+			fmt.Println("Aborted. Nothing was deleted.")
+			return nil
+		}
+	}
+
+	if err := client.DeleteProject(accessToken, project.ID); err != nil {
+		return checkSession(fmt.Errorf("failed to delete project: %w", err))
+	}
+
+	fmt.Println(ui.Success("Deleted project %s (%s)", project.Name, project.ID))
+	if cfg.GetSelectedProject() == project.ID {
+		if err := cfg.ClearSelectedProject(); err != nil {
+			return fmt.Errorf("failed to clear project selection: %w", err)
+		}
+		fmt.Println("   It was the selected project; select another with 'flux-relay pr <project-name-or-id>'.")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// assumeYes is set by --yes (or its alias --assume-yes)
+var assumeYes bool
+
+// stdinReader is shared by the prompts so input typed ahead of a question
+// isn't lost to an earlier one's buffer
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// assumingYes reports whether confirmations are answered yes without asking,
+// from --yes or the assume_yes setting
+func assumingYes() bool {
+	return assumeYes || viper.GetBool("assume_yes")
+}
+
+// confirm asks a yes/no question before a change is made and reports whether
+// the answer was yes. With --yes it returns true without asking; otherwise it
+// returns false without asking when stdin is not a terminal.
+func confirm(question string) bool {
+	if assumingYes() {
+		return true
+	}
+	return askYesNo(question)
+}
+
+// askYesNo asks a yes/no question on stdin whatever --yes says, for questions
+// that aren't confirmations. It returns false when stdin is not a terminal.
+func askYesNo(question string) bool {
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return isYes(answer)
+}
+
+// askLine asks for a line of text on stdin and returns it trimmed. It fails
+// at the end of input, e.g. on Ctrl+D.
+func askLine(question string) (string, error) {
+	fmt.Print(question)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("no answer given")
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// isYes reports whether an answer to a yes/no question means yes
+func isYes(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
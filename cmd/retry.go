@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+	"github.com/spf13/viper"
+)
+
+const (
+	// lockRetries is how often a statement that hit a locked database is
+	// retried with --retry-on-lock
+	lockRetries = 5
+	// lockRetryDelay is the wait before the first retry; it doubles after
+	// each one (0.1s, 0.2s, ... 1.6s)
+	lockRetryDelay = 100 * time.Millisecond
+)
+
+// sqlRetryOnLock retries statements that fail because the database is busy
+var sqlRetryOnLock bool
+
+// mutatingKeywords are the statements retried on a locked database. Reads
+// aren't retried.
+var mutatingKeywords = map[string]bool{
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"REPLACE": true,
+}
+
+// retryOnLockEnabled reports whether lock retries are on, via --retry-on-lock
+// or "retry_on_lock: true" in ~/.flux-relay/config.yaml
+func retryOnLockEnabled() bool {
+	return sqlRetryOnLock || viper.GetBool("retry_on_lock")
+}
+
+// isLockError reports whether an error message is SQLite's "database is
+// locked" or SQLITE_BUSY, which go away once the other writer finishes
+func isLockError(message string) bool {
+	message = strings.ToLower(message)
+	for _, text := range []string{"database is locked", "database table is locked", "sqlite_busy", "sqlite_locked"} {
+		if strings.Contains(message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockFailure reports whether a query failed because the database was locked
+func lockFailure(queryResponse *api.QueryResponse, err error) bool {
+	if err != nil {
+		return isLockError(err.Error())
+	}
+	return queryResponse != nil && !queryResponse.Success && isLockError(queryResponse.ErrorMessage)
+}
+
+// withLockRetry runs a query and, when lock retries are enabled and the query
+// changes rows, runs it again with backoff while it fails on a locked
+// database
+func withLockRetry(query string, run func() (*api.QueryResponse, error)) (*api.QueryResponse, error) {
+	queryResponse, err := run()
+	if !retryOnLockEnabled() || !mutatingKeywords[leadingKeyword(query)] {
+		return queryResponse, err
+	}
+
+	delay := lockRetryDelay
+	for attempt := 1; attempt <= lockRetries && lockFailure(queryResponse, err); attempt++ {
+		fmt.Fprintln(os.Stderr, ui.Warn("Database is locked, retrying in %s (%d/%d)", delay, attempt, lockRetries))
+		time.Sleep(delay)
+		delay *= 2
+		queryResponse, err = run()
+	}
+	return queryResponse, err
+}
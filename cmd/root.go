@@ -1,17 +1,37 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	cfgFile     string
-	apiBaseURL  string
-	verbose     bool
+	cfgFile      string
+	configDir    string
+	apiBaseURL   string
+	verbose      bool
+	outputFormat string
+	proxyURL     string
+	caCertFile   string
+	insecureTLS  bool
+)
+
+// Build information, set at build time via:
+//
+//	go build -ldflags "-X github.com/postacksol/flux-relay-cli/cmd.version=1.2.3 -X ...cmd.commit=abcdef1 -X ...cmd.date=2024-05-01"
+var (
+	version = "dev"
+	commit  = "dev"
+	date    = "dev"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -20,53 +40,126 @@ var rootCmd = &cobra.Command{
 	Short: "Flux Relay CLI - Manage your messaging platform from the command line",
 	Long: `Flux Relay CLI is a command-line tool for managing your Flux Relay
 messaging platform. Execute SQL queries, manage namespaces, and more.`,
-	Version: "1.0.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutputFormat(cmd)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if showVersion {
+			return runVersion(cmd, args)
+		}
+		return cmd.Help()
+	},
 }
 
+// showVersion is --version, which prints what the version command does.
+// Cobra's own --version runs before --output is checked, so it is a plain
+// flag handled by rootCmd instead.
+var showVersion bool
+
+// Exit codes. Commands return an exitCodeError to exit with something other
+// than exitError.
+const (
+	exitError           = 1   // any error, e.g. a failed query or bad flags
+	exitAssertionFailed = 2   // sql --fail-on-empty / --fail-on-rows did not hold
+	exitInterrupted     = 130 // a query was cancelled with Ctrl+C, as shells report SIGINT
+)
+
+// exitCodeError is an error that makes the CLI exit with a specific code
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(exitError)
 	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	// Identify this build to the API in the User-Agent header
+	api.Version = version
+
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.flux-relay/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "settings file (default is config.yaml in the config directory)")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "", "directory for the login, selections, history and config.yaml instead of ~/.flux-relay (default: FLUX_RELAY_CONFIG_DIR)")
 	rootCmd.PersistentFlags().StringVar(&apiBaseURL, "api-url", "", "API base URL (default: https://flux.postacksolutions.com)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "output format: table, json, yaml or csv")
+	rootCmd.PersistentFlags().StringVar(&caCertFile, "ca-cert", "", "PEM file of CAs to trust for the API, e.g. a self-hosted server's private CA")
+	rootCmd.PersistentFlags().BoolVar(&insecureTLS, "insecure", false, "skip verifying the API's TLS certificate (development only)")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "proxy for API requests, e.g. http://proxy:8080 or socks5://127.0.0.1:1080 (default: HTTPS_PROXY)")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to every confirmation prompt, e.g. in scripts")
+	rootCmd.PersistentFlags().BoolVar(&assumeYes, "assume-yes", false, "same as --yes")
+	rootCmd.PersistentFlags().MarkHidden("assume-yes")
+
+	rootCmd.Flags().BoolVar(&showVersion, "version", false, "version for flux-relay")
+	setOutputFormats(rootCmd, render.FormatJSON, render.FormatYAML) // for --version
 
 	// Bind flags to viper
 	viper.BindPFlag("api_url", rootCmd.PersistentFlags().Lookup("api-url"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("proxy", rootCmd.PersistentFlags().Lookup("proxy"))
+	viper.BindPFlag("ca_cert", rootCmd.PersistentFlags().Lookup("ca-cert"))
+	viper.BindPFlag("assume_yes", rootCmd.PersistentFlags().Lookup("yes"))
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// Every config.New() from here on uses --config-dir
+	config.Dir = configDir
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
-
-		// Search config in home directory with name ".flux-relay" (without extension).
-		viper.AddConfigPath(home + "/.flux-relay")
-		viper.AddConfigPath(".")
-		viper.SetConfigType("yaml")
-		viper.SetConfigName("config")
+		// Look for config.yaml in the config directory, then the current
+		// directory. The file is named explicitly; searching by name alone
+		// would also match config.json, which holds the token and is not a
+		// settings file.
+		for _, dir := range []string{config.DefaultDir(), "."} {
+			path := filepath.Join(dir, "config.yaml")
+			if _, err := os.Stat(path); err == nil {
+				viper.SetConfigFile(path)
+				break
+			}
+		}
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Read settings from FLUX_RELAY_* environment variables, e.g.
+	// FLUX_RELAY_API_URL. Without the prefix a generic variable such as
+	// ASSUME_YES would count, and silently confirm destructive commands.
+	viper.SetEnvPrefix("FLUX_RELAY")
+	viper.AutomaticEnv()
+
+	api.Verbose = verbose
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil && verbose {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+
+	// How API clients connect, from the flags or the settings file.
+	// --insecure is a flag only, so it is never left on by accident.
+	api.Transport = api.TransportConfig{
+		Proxy:    viper.GetString("proxy"),
+		CAFile:   viper.GetString("ca_cert"),
+		Insecure: insecureTLS,
+	}
+	if insecureTLS {
+		fmt.Fprintln(os.Stderr, ui.Warn("WARNING: --insecure is set. The API's TLS certificate is NOT verified, so anyone on the network can read and change requests, including your token. Use --ca-cert instead outside development."))
+	}
 }
 
 // getAPIURL returns the API URL from flag, config, or default production URL
@@ -77,6 +170,20 @@ func getAPIURL() string {
 	if url := viper.GetString("api_url"); url != "" {
 		return url
 	}
+	// The API the saved token was issued by
+	if url := config.New().GetAPIURL(); url != "" {
+		return url
+	}
 	// Default to production URL
 	return "https://flux.postacksolutions.com"
-}
\ No newline at end of file
+}
+
+// validateOutputFormat checks the --output flag value
+func validateOutputFormat() error {
+	switch outputFormat {
+	case "table", "json", "yaml", "csv":
+		return nil
+	default:
+		return fmt.Errorf("invalid output format '%s'. Must be 'table', 'json', 'yaml' or 'csv'", outputFormat)
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/spf13/viper"
+)
+
+// newAPIURLTestServer returns a fake API answering the list and query calls
+// with one project, server and nameserver, and a function returning the
+// number of requests it has received
+func newAPIURLTestServer(t *testing.T) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch path := r.URL.Path; {
+		case path == "/api/developer/me":
+			w.Write([]byte(`{"developer":{"id":"dev1","email":"dev@example.com"}}`))
+		case path == "/api/developer/projects":
+			w.Write([]byte(`{"projects":[{"id":"P1","name":"Proj","isActive":true}]}`))
+		case strings.HasSuffix(path, "/servers"):
+			w.Write([]byte(`{"servers":[{"id":"S1","name":"Main","isActive":true}]}`))
+		case strings.HasSuffix(path, "/databases"):
+			w.Write([]byte(`{"databases":[{"id":"D1","databaseName":"db","isActive":true}]}`))
+		case strings.HasSuffix(path, "/database/query"):
+			w.Write([]byte(`{"columns":["name","has_server_id"],"rows":[["messages_db",1]],"executionTime":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// loginTestConfigDir returns a config directory holding a login with a
+// project, server and nameserver selected
+func loginTestConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	cm := config.NewWithDir(dir)
+	token := &api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}
+	token.Developer.ID = "dev1"
+	if err := cm.SaveToken(token, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	for _, set := range []func() error{
+		func() error { return cm.SetSelectedProject("P1") },
+		func() error { return cm.SetSelectedServer("S1") },
+		func() error { return cm.SetSelectedNameserver("D1") },
+	} {
+		if err := set(); err != nil {
+			t.Fatalf("saving selection: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestCommandsHonorAPIURLFlag(t *testing.T) {
+	server, requests := newAPIURLTestServer(t)
+	dir := loginTestConfigDir(t)
+	t.Cleanup(func() {
+		apiBaseURL = ""
+		configDir = ""
+		config.Dir = ""
+	})
+
+	commands := [][]string{
+		{"pr", "list"},
+		{"projects", "list"},
+		{"server", "list"},
+		{"server", "describe"},
+		{"ns", "list"},
+		{"ns", "current"},
+		{"ns", "describe"},
+		{"ns", "tables"},
+		{"sql", "--no-history", "SELECT name FROM sqlite_master"},
+	}
+	for _, args := range commands {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			// --api-url goes after the subcommand's own arguments
+			rootCmd.SetArgs(append(append([]string{"--config-dir", dir}, args...), "--api-url", server.URL))
+			before := requests()
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if requests() == before {
+				t.Error("no request reached the --api-url server")
+			}
+		})
+	}
+}
+
+func TestGetAPIURLPrecedence(t *testing.T) {
+	config.Dir = t.TempDir()
+	t.Cleanup(func() {
+		apiBaseURL = ""
+		config.Dir = ""
+		viper.Set("api_url", nil)
+	})
+
+	if got := getAPIURL(); got != "https://flux.postacksolutions.com" {
+		t.Errorf("default: getAPIURL() = %q", got)
+	}
+
+	token := &api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}
+	if err := config.New().SaveToken(token, "https://saved.example.com"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if got := getAPIURL(); got != "https://saved.example.com" {
+		t.Errorf("saved: getAPIURL() = %q", got)
+	}
+
+	viper.Set("api_url", "https://settings.example.com")
+	if got := getAPIURL(); got != "https://settings.example.com" {
+		t.Errorf("settings: getAPIURL() = %q", got)
+	}
+
+	apiBaseURL = "https://flag.example.com"
+	if got := getAPIURL(); got != "https://flag.example.com" {
+		t.Errorf("flag: getAPIURL() = %q", got)
+	}
+}
+
+func TestAssumeYesOnlyFromPrefixedEnv(t *testing.T) {
+	t.Setenv("ASSUME_YES", "true")
+	t.Setenv("FLUX_RELAY_ASSUME_YES", "")
+	config.Dir = t.TempDir()
+	t.Cleanup(func() { config.Dir = "" })
+	initConfig()
+
+	if assumingYes() {
+		t.Error("assumingYes() = true with only ASSUME_YES set")
+	}
+
+	t.Setenv("FLUX_RELAY_ASSUME_YES", "true")
+	if !assumingYes() {
+		t.Error("assumingYes() = false with FLUX_RELAY_ASSUME_YES set")
+	}
+}
+
+func TestSelectionOverridesAreGlobal(t *testing.T) {
+	server, _ := newAPIURLTestServer(t)
+	dir := loginTestConfigDir(t)
+	t.Cleanup(func() {
+		apiBaseURL = ""
+		configDir = ""
+		config.Dir = ""
+		projectOverride, serverOverride, nameserverOverride = "", "", ""
+	})
+
+	run := func(args ...string) error {
+		projectOverride, serverOverride, nameserverOverride = "", "", ""
+		rootCmd.SetArgs(append([]string{"--config-dir", dir, "--api-url", server.URL}, args...))
+		return rootCmd.Execute()
+	}
+
+	for _, args := range [][]string{
+		{"server", "describe", "--project", "Proj", "--server", "Main"},
+		{"ns", "list", "--server", "Main"},
+		{"ns", "tables", "--nameserver", "db"},
+		{"sql", "--no-history", "--project", "P1", "SELECT 1"},
+	} {
+		if err := run(args...); err != nil {
+			t.Errorf("%v: %v", args, err)
+		}
+	}
+
+	// Commands that save the selection refuse an override rather than ignore it
+	err := run("ns", "use", "db", "--server", "Main")
+	if err == nil || !strings.Contains(err.Error(), "--server can't be used") {
+		t.Errorf("ns use --server: error = %v, want it rejected", err)
+	}
+}
+
+func TestOutputFormatRejectedWhereUnsupported(t *testing.T) {
+	server, _ := newAPIURLTestServer(t)
+	dir := loginTestConfigDir(t)
+	t.Cleanup(func() {
+		apiBaseURL = ""
+		configDir = ""
+		config.Dir = ""
+		outputFormat = "table"
+		nsListAllServers = false
+	})
+
+	run := func(args ...string) error {
+		outputFormat = "table"
+		rootCmd.SetArgs(append([]string{"--config-dir", dir, "--api-url", server.URL}, args...))
+		return rootCmd.Execute()
+	}
+
+	for _, args := range [][]string{
+		{"pr", "list", "-o", "csv"},
+		{"ping", "-o", "csv"},
+		{"ns", "list", "-o", "json"},
+		{"ns", "list", "--all-servers", "-o", "csv"},
+	} {
+		if err := run(args...); err == nil {
+			t.Errorf("%v: no error, want the format rejected", args)
+		}
+	}
+
+	if err := run("sql", "--no-history", "-o", "csv", "SELECT 1"); err != nil {
+		t.Errorf("sql -o csv: %v", err)
+	}
+}
+
+func TestSQLSummaryRejectedWithoutTransaction(t *testing.T) {
+	dir := loginTestConfigDir(t)
+	t.Cleanup(func() {
+		configDir = ""
+		config.Dir = ""
+		sqlSummary, sqlTransaction, sqlDryRun = false, false, false
+	})
+
+	for _, args := range [][]string{
+		{"sql", "--no-history", "--summary", "SELECT 1"},
+		{"sql", "--no-history", "--transaction", "--summary", "--dry-run", "SELECT 1"},
+	} {
+		sqlSummary, sqlTransaction, sqlDryRun = false, false, false
+		rootCmd.SetArgs(append([]string{"--config-dir", dir, "--api-url", "http://127.0.0.1:0"}, args...))
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--summary") {
+			t.Errorf("%v: error = %v, want --summary rejected", args, err)
+		}
+	}
+}
+
+// captureStdout returns what run writes to os.Stdout
+func captureStdout(t *testing.T, run func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	runErr := run()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading stdout: %v", err)
+	}
+	return string(out), runErr
+}
+
+func TestVersionOutput(t *testing.T) {
+	t.Cleanup(func() {
+		outputFormat = "table"
+		showVersion = false
+	})
+
+	run := func(args ...string) (string, error) {
+		outputFormat = "table"
+		showVersion = false
+		rootCmd.SetArgs(args)
+		return captureStdout(t, rootCmd.Execute)
+	}
+
+	for _, args := range [][]string{
+		{"version", "-o", "json"},
+		{"--version", "-o", "json"},
+		{"-o", "json", "--version"},
+	} {
+		out, err := run(args...)
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		var info map[string]string
+		if err := json.Unmarshal([]byte(out), &info); err != nil {
+			t.Fatalf("%v: output isn't JSON: %v\n%s", args, err, out)
+		}
+		for _, key := range []string{"version", "commit", "date", "goVersion"} {
+			if info[key] == "" {
+				t.Errorf("%v: key %q missing from %s", args, key, out)
+			}
+		}
+	}
+
+	// --version checks --output like the version command does
+	for _, args := range [][]string{
+		{"version", "-o", "bogus"},
+		{"--version", "-o", "bogus"},
+	} {
+		if _, err := run(args...); err == nil {
+			t.Errorf("%v: no error, want the format rejected", args)
+		}
+	}
+}
+
+func TestDryRunWarnsAboutUnscopedMutations(t *testing.T) {
+	server, _ := newAPIURLTestServer(t)
+	dir := loginTestConfigDir(t)
+	t.Cleanup(func() {
+		apiBaseURL = ""
+		configDir = ""
+		config.Dir = ""
+		sqlDryRun = false
+	})
+
+	rootCmd.SetArgs([]string{"--config-dir", dir, "--api-url", server.URL,
+		"sql", "--no-history", "--dry-run", "DELETE FROM messages_db WHERE id = 1"})
+	out, err := captureStdout(t, rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("dry run refused an unscoped DELETE: %v", err)
+	}
+	if !strings.Contains(out, "needs --force") {
+		t.Errorf("dry run output doesn't warn about the unscoped DELETE:\n%s", out)
+	}
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/config"
+)
+
+// Global overrides for the saved project/server/nameserver selection. They
+// apply to a single invocation and are never persisted. Commands read them
+// through currentProjectID, currentServerID and currentNameserverID.
+var (
+	projectOverride    string
+	serverOverride     string
+	nameserverOverride string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&projectOverride, "project", "", "project name or ID to use instead of the selected project")
+	rootCmd.PersistentFlags().StringVar(&serverOverride, "server", "", "server name or ID to use instead of the selected server")
+	rootCmd.PersistentFlags().StringVar(&nameserverOverride, "nameserver", "", "nameserver name or ID to use instead of the selected nameserver")
+}
+
+// rejectSelectionOverrides fails for commands that show or save the saved
+// selection itself, where an override would be ambiguous
+func rejectSelectionOverrides(command string) error {
+	overrides := []struct{ flag, value string }{
+		{"project", projectOverride},
+		{"server", serverOverride},
+		{"nameserver", nameserverOverride},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			return fmt.Errorf("--%s can't be used with '%s', which works on the saved selection. Change the selection with 'flux-relay pr', 'flux-relay server' or 'flux-relay ns use' instead", override.flag, command)
+		}
+	}
+	return nil
+}
+
+// ambiguousError reports an identifier that matched several resources by name
+func ambiguousError(kind, identifier string, candidates []string) error {
+	return fmt.Errorf("ambiguous identifier '%s': it matches %d %ss; specify the ID instead:\n  %s",
+		identifier, len(candidates), kind, strings.Join(candidates, "\n  "))
+}
+
+// resolveProject finds a project by exact ID or case-insensitive name
+func resolveProject(client *api.Client, accessToken, identifier string) (*api.Project, error) {
+	projectsResponse, err := client.ListProjects(accessToken)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	var matches []*api.Project
+	for i := range projectsResponse.Projects {
+		project := &projectsResponse.Projects[i]
+		if project.ID == identifier {
+			return project, nil
+		}
+		if strings.EqualFold(project.Name, identifier) {
+			matches = append(matches, project)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("project '%s' not found. Use 'flux-relay pr list' to see available projects", identifier)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, project := range matches {
+		candidates[i] = fmt.Sprintf("%s (ID: %s)", project.Name, project.ID)
+	}
+	return nil, ambiguousError("project", identifier, candidates)
+}
+
+// resolveServer finds a server in a project by exact ID or case-insensitive name
+func resolveServer(client *api.Client, accessToken, projectID, identifier string) (*api.Server, error) {
+	serversResponse, err := client.ListServers(accessToken, projectID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+
+	var matches []*api.Server
+	for i := range serversResponse.Servers {
+		server := &serversResponse.Servers[i]
+		if server.ID == identifier {
+			return server, nil
+		}
+		if strings.EqualFold(server.Name, identifier) {
+			matches = append(matches, server)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("server '%s' not found. Use 'flux-relay server list' to see available servers", identifier)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, server := range matches {
+		candidates[i] = fmt.Sprintf("%s (ID: %s)", server.Name, server.ID)
+	}
+	return nil, ambiguousError("server", identifier, candidates)
+}
+
+// resolveNameserver finds a nameserver in a server by exact ID or case-insensitive name
+func resolveNameserver(client *api.Client, accessToken, projectID, serverID, identifier string) (*api.Database, error) {
+	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("failed to list nameservers: %w", err)
+	}
+
+	var matches []*api.Database
+	for i := range databasesResponse.Databases {
+		ns := &databasesResponse.Databases[i]
+		if ns.ID == identifier {
+			return ns, nil
+		}
+		if strings.EqualFold(ns.DatabaseName, identifier) {
+			matches = append(matches, ns)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("nameserver '%s' not found. Use 'flux-relay ns list' to see available nameservers", identifier)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, ns := range matches {
+		status := ""
+		if !ns.IsActive {
+			status = " [inactive]"
+		}
+		candidates[i] = fmt.Sprintf("%s (ID: %s)%s", ns.DatabaseName, ns.ID, status)
+	}
+	return nil, ambiguousError("nameserver", identifier, candidates)
+}
+
+// currentProjectID returns the --project override resolved by name or ID,
+// or the saved project selection
+func currentProjectID(cfg *config.ConfigManager, client *api.Client, accessToken string) (string, error) {
+	if projectOverride == "" {
+		projectID := cfg.GetSelectedProject()
+		if projectID == "" {
+			return "", fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+		}
+		return projectID, nil
+	}
+
+	project, err := resolveProject(client, accessToken, projectOverride)
+	if err != nil {
+		return "", err
+	}
+	return project.ID, nil
+}
+
+// currentServerID returns the --server override resolved by name or ID, or
+// the saved server selection. The saved server is ignored when --project
+// points somewhere else, just like selecting a project clears the server.
+func currentServerID(cfg *config.ConfigManager, client *api.Client, accessToken, projectID string) (string, error) {
+	if serverOverride == "" {
+		serverID := cfg.GetSelectedServer()
+		if projectOverride != "" && projectID != cfg.GetSelectedProject() {
+			serverID = ""
+		}
+		if serverID == "" {
+			return "", fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server, or pass --server")
+		}
+		return serverID, nil
+	}
+
+	server, err := resolveServer(client, accessToken, projectID, serverOverride)
+	if err != nil {
+		return "", err
+	}
+	return server.ID, nil
+}
+
+// currentNameserverID returns the --nameserver override resolved by name or
+// ID, or the saved nameserver selection. An empty ID means no nameserver is
+// selected; the saved one is ignored when --server points somewhere else.
+func currentNameserverID(cfg *config.ConfigManager, client *api.Client, accessToken, projectID, serverID string) (string, error) {
+	if nameserverOverride == "" {
+		if serverID != cfg.GetSelectedServer() {
+			return "", nil
+		}
+		return cfg.GetSelectedNameserver(), nil
+	}
+
+	ns, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverOverride)
+	if err != nil {
+		return "", err
+	}
+	return ns.ID, nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var serverCmd = &cobra.Command{
@@ -34,23 +37,86 @@ var serverListCmd = &cobra.Command{
 	RunE:  runServerList,
 }
 
+// serverShellNameserver is the nameserver 'server shell' opens in
+var serverShellNameserver string
+
 var serverShellCmd = &cobra.Command{
 	Use:   "shell [server-name-or-id]",
 	Short: "Open interactive SQL shell for a server",
 	Long: `Open an interactive SQL shell for a server, similar to Turso's shell.
 
+If no server is specified, the shell opens on the currently selected
+server and nameserver.
+
+Use --nameserver (or --ns) to open the shell in one nameserver's context,
+as if running '.use <nameserver>' on entry.
+
 Examples:
   flux-relay server shell MyServer
-  flux-relay srv shell server_123`,
-	Args: cobra.ExactArgs(1),
+  flux-relay server shell MyServer --ns db2
+  flux-relay srv shell server_123
+  flux-relay server shell             # Resume the current selection`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runServerShell(args[0])
+		if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+			return err
+		}
+		// A statement that fails in a piped script is already reported
+		cmd.SilenceUsage = true
+		if len(args) == 0 {
+			return runServerShell("", serverShellNameserver)
+		}
+		return runServerShell(args[0], serverShellNameserver)
 	},
 }
 
+var serverDescribeCmd = &cobra.Command{
+	Use:   "describe [server-name-or-id]",
+	Short: "Show all details of a server",
+	Long: `Show every field of a server, including timestamps, API key status,
+database URL (with tokens masked) and nameserver counts.
+
+If no server is specified, the currently selected server is described.
+
+Examples:
+  flux-relay server describe
+  flux-relay server describe MyServer`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runServerDescribe,
+}
+
+var serverRenameCmd = &cobra.Command{
+	Use:   "rename <server-name-or-id>",
+	Short: "Change a server's name or description",
+	Long: `Change the name and/or description of a server in the selected project.
+
+Pass --description "" to clear the description. A server keeps its ID, so
+the saved selection and anything using the ID are not affected.
+
+Examples:
+  flux-relay server rename MyServer --name Production
+  flux-relay server rename server_123 --description "EU customers"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runServerRename,
+}
+
+var serverRenameName string
+var serverRenameDescription string
+
 func init() {
+	serverRenameCmd.Flags().StringVar(&serverRenameName, "name", "", "New server name")
+	serverRenameCmd.Flags().StringVar(&serverRenameDescription, "description", "", "New server description")
+	serverCmd.AddCommand(serverRenameCmd)
 	serverCmd.AddCommand(serverListCmd)
+	serverCmd.AddCommand(serverDescribeCmd)
 	serverCmd.AddCommand(serverShellCmd)
+	serverShellCmd.Flags().StringVar(&serverShellNameserver, "nameserver", "", "Open the shell in this nameserver (name or ID); alias --ns")
+	serverShellCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "ns" {
+			name = "nameserver"
+		}
+		return pflag.NormalizedName(name)
+	})
 	rootCmd.AddCommand(serverCmd)
 	
 	// Add 'srv' as an alias for 'server'
@@ -70,19 +136,20 @@ func runServerList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project
-	projectID := cfg.GetSelectedProject()
-	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+	client := api.NewClient(apiURL)
+
+	// Get selected project (or the --project override)
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
 	}
 
-	// Create API client and list servers
-	client := api.NewClient(apiURL)
+	// List servers
 	serversResponse, err := client.ListServers(accessToken, projectID)
 	if err != nil {
 		if apiErr, ok := err.(*api.APIError); ok {
-			if apiErr.Code() == "Unauthorized" || apiErr.Code() == "unauthorized" {
-				return fmt.Errorf("authentication failed. Please run 'flux-relay login' again")
+			if api.IsUnauthorized(apiErr) {
+				return errSessionExpired
 			}
 			if apiErr.Code() == "Project not found" || apiErr.Code() == "project not found" {
 				return fmt.Errorf("project not found. Use 'flux-relay pr <project-name-or-id>' to select a valid project")
@@ -193,7 +260,7 @@ func runServerList(cmd *cobra.Command, args []string) error {
 	for i, err := range errors {
 		if err != nil {
 			if !hasErrors {
-				fmt.Println("⚠️  Warnings:")
+				fmt.Println(ui.Warn("Warnings:"))
 				hasErrors = true
 			}
 			fmt.Printf("  Could not get nameserver count for server %s: %v\n", servers[i].ID, err)
@@ -207,6 +274,10 @@ func runServerList(cmd *cobra.Command, args []string) error {
 }
 
 func runServerShowOrSelect(cmd *cobra.Command, args []string) error {
+	if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+		return err
+	}
+
 	// Get API URL
 	apiURL := getAPIURL()
 
@@ -255,7 +326,7 @@ func runServerShowOrSelect(cmd *cobra.Command, args []string) error {
 		}
 
 		if selectedServer == nil {
-			fmt.Printf("⚠️  Selected server (ID: %s) not found.\n", selectedServerID)
+			fmt.Println(ui.Warn("Selected server (ID: %s) not found.", selectedServerID))
 			fmt.Println("Please select a different server.")
 			return nil
 		}
@@ -284,26 +355,11 @@ func runServerShowOrSelect(cmd *cobra.Command, args []string) error {
 	// If argument provided, treat as server selection
 	serverIdentifier := strings.Join(args, " ")
 
-	// Get all servers
+	// Find server by ID or name (case-insensitive)
 	client := api.NewClient(apiURL)
-	serversResponse, err := client.ListServers(accessToken, projectID)
+	selectedServer, err := resolveServer(client, accessToken, projectID, serverIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to list servers: %w", err)
-	}
-
-	// Find server by ID or name (case-insensitive)
-	var selectedServer *api.Server
-	for i := range serversResponse.Servers {
-		server := &serversResponse.Servers[i]
-		if server.ID == serverIdentifier || 
-		   strings.EqualFold(server.Name, serverIdentifier) {
-			selectedServer = server
-			break
-		}
-	}
-
-	if selectedServer == nil {
-		return fmt.Errorf("server '%s' not found. Use 'flux-relay server list' to see available servers", serverIdentifier)
+		return err
 	}
 
 	// Save selected server
@@ -311,15 +367,225 @@ func runServerShowOrSelect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save server selection: %w", err)
 	}
 
-	fmt.Printf("✅ Selected server: %s (%s)\n", selectedServer.Name, selectedServer.ID)
+	fmt.Println(ui.Success("Selected server: %s (%s)", selectedServer.Name, selectedServer.ID))
 	if selectedServer.Description != "" {
 		fmt.Printf("   Description: %s\n", selectedServer.Description)
 	}
 	fmt.Println()
 	fmt.Println("You can now use:")
 	fmt.Println("  flux-relay ns list              # List nameservers")
-	fmt.Println("  flux-relay ns <nameserver-name> # Select nameserver")
+	fmt.Println("  flux-relay ns use <nameserver-name> # Select nameserver")
 	fmt.Println("  flux-relay sql <query>          # Execute SQL query")
 
 	return nil
 }
+
+// validateServerName checks a new server name
+func validateServerName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("server name cannot be empty")
+	}
+	if len(name) > 100 {
+		return fmt.Errorf("server name must be 1-100 characters")
+	}
+	if strings.ContainsAny(name, "\r\n\t") {
+		return fmt.Errorf("server name cannot contain tabs or line breaks")
+	}
+	return nil
+}
+
+func runServerRename(cmd *cobra.Command, args []string) error {
+	nameChanged := cmd.Flags().Changed("name")
+	descriptionChanged := cmd.Flags().Changed("description")
+	if !nameChanged && !descriptionChanged {
+		return fmt.Errorf("nothing to change. Pass --name and/or --description")
+	}
+	var update api.UpdateServerRequest
+	if nameChanged {
+		if err := validateServerName(serverRenameName); err != nil {
+			return err
+		}
+		update.Name = &serverRenameName
+	}
+	if descriptionChanged {
+		update.Description = &serverRenameDescription
+	}
+
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+	server, err := resolveServer(client, accessToken, projectID, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Names select servers, so they must stay unique within the project
+	if nameChanged && !strings.EqualFold(serverRenameName, server.Name) {
+		serversResponse, err := client.ListServers(accessToken, projectID)
+		if err != nil {
+			return checkSession(fmt.Errorf("failed to list servers: %w", err))
+		}
+		for _, other := range serversResponse.Servers {
+			if other.ID != server.ID && strings.EqualFold(other.Name, serverRenameName) {
+				return fmt.Errorf("a server named '%s' already exists in this project (%s)", other.Name, other.ID)
+			}
+		}
+	}
+
+	updated, err := client.RenameServer(accessToken, projectID, server.ID, update)
+	if err != nil {
+		return checkSession(fmt.Errorf("failed to update server: %w", err))
+	}
+	// Fill in what the API left out of its response
+	if updated.ID == "" {
+		updated.ID = server.ID
+	}
+	if updated.Name == "" {
+		updated.Name = server.Name
+		if nameChanged {
+			updated.Name = serverRenameName
+		}
+	}
+
+	fmt.Println(ui.Success("Updated server %s", updated.ID))
+	if nameChanged {
+		fmt.Printf("   Name: %s → %s\n", server.Name, updated.Name)
+	}
+	if descriptionChanged {
+		fmt.Printf("   Description: %s\n", valueOrDash(serverRenameDescription))
+	}
+	if cfg.GetSelectedServer() == updated.ID {
+		fmt.Println("   This is the selected server; the selection is kept.")
+	}
+	return nil
+}
+
+func runServerDescribe(cmd *cobra.Command, args []string) error {
+	// Get API URL
+	apiURL := getAPIURL()
+
+	// Get access token
+	cfg := config.New()
+	accessToken := cfg.GetAccessToken()
+	if accessToken == "" {
+		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	}
+
+	client := api.NewClient(apiURL)
+
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+
+	// The argument takes precedence over --server and the saved selection
+	if len(args) == 1 {
+		serverOverride = args[0]
+	}
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
+	}
+	server, err := resolveServer(client, accessToken, projectID, serverID)
+	if err != nil {
+		return err
+	}
+
+	nameservers := "-"
+	databasesResponse, err := client.ListDatabases(accessToken, projectID, server.ID)
+	if err != nil {
+		nameservers = fmt.Sprintf("unknown (%v)", err)
+	} else {
+		active := 0
+		for _, db := range databasesResponse.Databases {
+			if db.IsActive {
+				active++
+			}
+		}
+		nameservers = fmt.Sprintf("%d active, %d inactive", active, len(databasesResponse.Databases)-active)
+	}
+
+	fmt.Printf("Server: %s\n", server.Name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "   ID:\t%s\n", server.ID)
+	fmt.Fprintf(w, "   Description:\t%s\n", valueOrDash(server.Description))
+	fmt.Fprintf(w, "   Status:\t%s\n", activeStatus(server.IsActive))
+	fmt.Fprintf(w, "   API key:\t%s\n", yesNo(server.HasApiKey))
+	fmt.Fprintf(w, "   Database URL:\t%s\n", valueOrDash(maskURLSecrets(server.DatabaseURL)))
+	fmt.Fprintf(w, "   Nameservers:\t%s\n", nameservers)
+	fmt.Fprintf(w, "   Created:\t%s\n", valueOrDash(server.CreatedAt))
+	fmt.Fprintf(w, "   Updated:\t%s\n", valueOrDash(server.UpdatedAt))
+	w.Flush()
+
+	return nil
+}
+
+// maskURLSecrets hides credentials embedded in a database URL: the userinfo
+// password and query parameters such as authToken
+func maskURLSecrets(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(hidden: unparseable URL)"
+	}
+
+	const masked = "REDACTED"
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), masked)
+		} else {
+			// A bare userinfo is usually a token, e.g. https://<token>@host
+			u.User = url.User(masked)
+		}
+	}
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		for _, secret := range []string{"token", "key", "secret", "password", "auth"} {
+			if strings.Contains(lower, secret) {
+				query.Set(key, masked)
+				break
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func activeStatus(active bool) string {
+	if active {
+		return "Active"
+	}
+	return "Inactive"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
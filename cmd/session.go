@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+)
+
+// errSessionExpired replaces the raw 401 error when the API rejects the
+// stored token, e.g. because it expired during a long-running command
+var errSessionExpired = errors.New("your session has expired. Run 'flux-relay login' to sign in again")
+
+// checkSession returns errSessionExpired if err is an API rejection of the
+// access token, and err unchanged otherwise
+func checkSession(err error) error {
+	if api.IsUnauthorized(err) {
+		return errSessionExpired
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// settingsPath returns the settings file in use, or config.yaml in the
+// config directory if there is none yet
+func settingsPath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	return filepath.Join(config.DefaultDir(), "config.yaml"), nil
+}
+
+// saveSetting writes key: value to the settings file, creating the file if
+// needed. Other settings and comments in the file are kept. It returns the
+// path written.
+func saveSetting(key, value string) (string, error) {
+	path, err := settingsPath()
+	if err != nil {
+		return "", err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return "", err
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return "", fmt.Errorf("%s could not be parsed: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	settings := doc.Content[0]
+	if settings.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("%s must be a mapping of setting names to values", path)
+	}
+
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	replaced := false
+	for i := 0; i+1 < len(settings.Content); i += 2 {
+		if settings.Content[i].Value == key {
+			valueNode.LineComment = settings.Content[i+1].LineComment
+			settings.Content[i+1] = valueNode
+			replaced = true
+		}
+	}
+	if !replaced {
+		settings.Content = append(settings.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, valueNode)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	viper.Set(key, value)
+	return path, nil
+}
@@ -2,21 +2,29 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 )
 
-// runServerShell starts an interactive shell for a server
-func runServerShell(serverIdentifier string) error {
+// runServerShell starts an interactive shell for a server, scoped to
+// nameserverIdentifier if it is set. An empty server identifier resumes the
+// saved server and nameserver selection.
+func runServerShell(serverIdentifier, nameserverIdentifier string) error {
 	// Get API URL
 	apiURL := getAPIURL()
 
@@ -33,36 +41,62 @@ func runServerShell(serverIdentifier string) error {
 		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
 	}
 
+	// Fall back to the saved selection when no server is given
+	resume := false
+	if serverIdentifier == "" {
+		serverIdentifier = cfg.GetSelectedServer()
+		if serverIdentifier == "" {
+			return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server, or specify one: flux-relay server shell <name>")
+		}
+		resume = true
+	}
+
 	// Find server by ID or name
 	client := api.NewClient(apiURL)
-	serversResponse, err := client.ListServers(accessToken, projectID)
+	selectedServer, err := resolveServer(client, accessToken, projectID, serverIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to list servers: %w", err)
+		return err
 	}
 
-	var selectedServer *api.Server
-	for i := range serversResponse.Servers {
-		server := &serversResponse.Servers[i]
-		if server.ID == serverIdentifier || strings.EqualFold(server.Name, serverIdentifier) {
-			selectedServer = server
-			break
+	// An explicit server replaces the selection
+	if !resume {
+		if err := cfg.SetSelectedServer(selectedServer.ID); err != nil {
+			return fmt.Errorf("failed to save server selection: %w", err)
 		}
 	}
 
-	if selectedServer == nil {
-		return fmt.Errorf("server '%s' not found", serverIdentifier)
-	}
-
-	// Save selected server
-	if err := cfg.SetSelectedServer(selectedServer.ID); err != nil {
-		return fmt.Errorf("failed to save server selection: %w", err)
+	// --nameserver opens in that nameserver, like .use on entry; otherwise
+	// resuming keeps the saved nameserver
+	nameserverName := ""
+	if nameserverIdentifier != "" {
+		nameserver, err := resolveNameserver(client, accessToken, projectID, selectedServer.ID, nameserverIdentifier)
+		if err != nil {
+			return err
+		}
+		nameserverName = nameserver.DatabaseName
+		if err := cfg.SetSelectedNameserver(nameserver.ID); err != nil {
+			return fmt.Errorf("failed to save nameserver selection: %w", err)
+		}
+	} else if resume {
+		if selectedNameserverID := cfg.GetSelectedNameserver(); selectedNameserverID != "" {
+			databasesResponse, err := client.ListDatabases(accessToken, projectID, selectedServer.ID)
+			if err == nil {
+				for _, db := range databasesResponse.Databases {
+					if db.ID == selectedNameserverID {
+						nameserverName = db.DatabaseName
+						break
+					}
+				}
+			}
+		}
 	}
 
 	// Start interactive shell
-	return startShell(cfg, client, accessToken, projectID, selectedServer.ID, selectedServer.Name, "")
+	return startShell(cfg, client, accessToken, projectID, selectedServer.ID, selectedServer.Name, nameserverName)
 }
 
-// runNameserverShell starts an interactive shell for a nameserver
+// runNameserverShell starts an interactive shell for a nameserver.
+// An empty identifier uses the saved nameserver selection.
 func runNameserverShell(nameserverIdentifier string) error {
 	// Get API URL
 	apiURL := getAPIURL()
@@ -85,24 +119,18 @@ func runNameserverShell(nameserverIdentifier string) error {
 		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
 	}
 
-	// Find nameserver by ID or name
-	client := api.NewClient(apiURL)
-	databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
-	if err != nil {
-		return fmt.Errorf("failed to list nameservers: %w", err)
-	}
-
-	var selectedNameserver *api.Database
-	for i := range databasesResponse.Databases {
-		ns := &databasesResponse.Databases[i]
-		if ns.ID == nameserverIdentifier || strings.EqualFold(ns.DatabaseName, nameserverIdentifier) {
-			selectedNameserver = ns
-			break
+	if nameserverIdentifier == "" {
+		nameserverIdentifier = cfg.GetSelectedNameserver()
+		if nameserverIdentifier == "" {
+			return fmt.Errorf("no nameserver selected. Use 'flux-relay ns use <nameserver-name-or-id>' to select a nameserver, or specify one: flux-relay ns shell <name>")
 		}
 	}
 
-	if selectedNameserver == nil {
-		return fmt.Errorf("nameserver '%s' not found", nameserverIdentifier)
+	// Find nameserver by ID or name
+	client := api.NewClient(apiURL)
+	selectedNameserver, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverIdentifier)
+	if err != nil {
+		return err
 	}
 
 	// Save selected nameserver
@@ -128,6 +156,9 @@ func runNameserverShell(nameserverIdentifier string) error {
 	return startShell(cfg, client, accessToken, projectID, serverID, serverName, selectedNameserver.DatabaseName)
 }
 
+// tableNamePattern matches plain SQL identifiers that are safe to interpolate
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // Shell context to track current nameserver
 type shellContext struct {
 	projectID      string
@@ -138,6 +169,24 @@ type shellContext struct {
 	client         *api.Client
 	accessToken    string
 	cfg            *config.ConfigManager
+	autoSuffix     bool // rewrite unsuffixed table names to the current nameserver
+	pager          bool // page results taller than the terminal through $PAGER
+	interactive    bool   // stdin is a terminal; false when a script is piped in
+	lastQuery      string // the statement last run, as typed, for .save
+	queryTimeout   time.Duration // set by .timeout; 0 for the client default
+	queryClient    *api.Client   // runs queries when queryTimeout is set
+}
+
+// prepareQuery applies shell-level rewrites to a query before it is sent
+func (ctx *shellContext) prepareQuery(query string) string {
+	if !ctx.autoSuffix || ctx.nameserverName == "" {
+		return query
+	}
+	rewritten := addNameserverSuffix(query, ctx.nameserverName)
+	if rewritten != query {
+		fmt.Printf("(autosuffix) %s\n", rewritten)
+	}
+	return rewritten
 }
 
 // startShell runs the interactive SQL shell
@@ -145,14 +194,8 @@ func startShell(cfg *config.ConfigManager, client *api.Client, accessToken, proj
 	// Get nameserver ID if nameserver name is provided
 	var nameserverID string
 	if nameserverName != "" {
-		databasesResponse, err := client.ListDatabases(accessToken, projectID, serverID)
-		if err == nil {
-			for _, db := range databasesResponse.Databases {
-				if db.DatabaseName == nameserverName {
-					nameserverID = db.ID
-					break
-				}
-			}
+		if ns, err := resolveNameserver(client, accessToken, projectID, serverID, nameserverName); err == nil {
+			nameserverID = ns.ID
 		}
 	}
 
@@ -165,13 +208,14 @@ func startShell(cfg *config.ConfigManager, client *api.Client, accessToken, proj
 		client:         client,
 		accessToken:    accessToken,
 		cfg:            cfg,
+		pager:          true,
 	}
 
 	return startShellWithContext(ctx)
 }
 
-func startShellWithContext(ctx *shellContext) error {
-	// Print welcome message
+// printWelcome prints the banner shown when an interactive shell starts
+func (ctx *shellContext) printWelcome() {
 	fmt.Printf("Connected to %s", ctx.serverName)
 	if ctx.nameserverName != "" {
 		fmt.Printf(" (nameserver: %s)", ctx.nameserverName)
@@ -184,25 +228,69 @@ func startShellWithContext(ctx *shellContext) error {
 	fmt.Println()
 	fmt.Println("Enter SQL queries directly (no 'sql' prefix needed).")
 	fmt.Println("End queries with semicolon (;) or press Enter twice to execute.")
+	fmt.Println()
+	ctx.printContextBanner()
+	fmt.Println()
+}
+
+// printContextBanner prints the nameserver queries run against, or that
+// the shell covers the whole server
+func (ctx *shellContext) printContextBanner() {
 	if ctx.nameserverName != "" {
-		fmt.Println()
 		fmt.Printf("📌 Current nameserver: %s\n", ctx.nameserverName)
 		fmt.Printf("Note: Tables use nameserver suffix. Example: conversations_%s\n", ctx.nameserverName)
 		fmt.Println("Use .tables to see all available tables.")
 	} else {
-		fmt.Println()
 		fmt.Println("📌 Server context: All nameservers")
 		fmt.Println("Use .nameservers to see available nameservers")
 		fmt.Println("Use .use <nameserver> to switch to a specific nameserver")
 	}
-	fmt.Println()
+}
+
+func startShellWithContext(ctx *shellContext) error {
+	// Piped input (flux-relay ns shell db < script.sql) runs as a batch:
+	// no banner or prompts, and the first failing statement stops it
+	ctx.interactive = isTerminal(os.Stdin)
+	if ctx.interactive {
+		ctx.printWelcome()
+	}
 
 	scanner := bufio.NewScanner(os.Stdin)
 	var currentQuery strings.Builder
+	lineNumber := 0
 
-	// Set up signal handler for Ctrl+C (like Turso - never exits, only .quit does)
+	// runQuery executes a complete statement. A failure ends a batch run.
+	// Dot commands such as .tables and .schema run their own queries and
+	// aren't checked for a server_id condition.
+	runQuery := func(query string) error {
+		if keyword := unscopedMutation(query); keyword != "" {
+			if !ctx.interactive {
+				return fmt.Errorf("line %d: refusing to run %s without server_id in its WHERE clause; use 'flux-relay sql --force' if that is intended", lineNumber, keyword)
+			}
+			fmt.Println(ui.Warn("This %s has no server_id condition in its WHERE clause.", keyword))
+			fmt.Println("   It could change other servers' rows, or every row in the table.")
+			fmt.Print("Run it anyway? [y/N]: ")
+			if !scanner.Scan() || !isYes(scanner.Text()) {
+				fmt.Println("Statement not run.")
+				return nil
+			}
+		}
+		ok := ctx.executeQuery(ctx.prepareQuery(query))
+		// Only a statement that was sent counts for .save, not a declined one
+		ctx.lastQuery = query
+		if !ok && !ctx.interactive {
+			return fmt.Errorf("statement ending on line %d failed; stopping", lineNumber)
+		}
+		return nil
+	}
+
+	// Set up signal handler for Ctrl+C (like Turso - never exits, only .quit does).
+	// A batch run keeps the default, so Ctrl+C stops it.
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGINT)
+	if ctx.interactive {
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGINT)
+		defer signal.Stop(sigChan)
+	}
 
 	// Handle Ctrl+C in a goroutine - never exits, only cancels queries
 	go func() {
@@ -225,27 +313,51 @@ func startShellWithContext(ctx *shellContext) error {
 
 	for {
 		// Show prompt
-		if currentQuery.Len() == 0 {
-			fmt.Print("→ ")
-		} else {
-			fmt.Print("  ")
+		if ctx.interactive {
+			if currentQuery.Len() == 0 {
+				fmt.Print("→ ")
+			} else {
+				fmt.Print("  ")
+			}
 		}
 
 		if !scanner.Scan() {
-			break
+			// End of input (Ctrl+D, or the end of piped input) is the same as .quit
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("error reading input: %w", err)
+			}
+			if !ctx.interactive {
+				// A script's last statement doesn't need a closing ';'
+				if pending := strings.TrimSpace(currentQuery.String()); pending != "" {
+					return runQuery(strings.TrimSuffix(pending, ";"))
+				}
+				return nil
+			}
+			fmt.Println()
+			if pending := strings.TrimSpace(currentQuery.String()); pending != "" {
+				fmt.Println(ui.Warn("Discarded unfinished query: %s", firstLine(pending)))
+			}
+			fmt.Println("Goodbye!")
+			return nil
 		}
 
 		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
+
+		// A long session can outlive its token; use the latest saved one
+		ctx.reloadToken()
 
 		// Handle empty lines
 		if line == "" {
 			if currentQuery.Len() > 0 {
 				// Empty line after query - execute it
 				query := strings.TrimSpace(currentQuery.String())
+				currentQuery.Reset()
 				if query != "" {
-					executeQuery(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID, query)
+					if err := runQuery(query); err != nil {
+						return err
+					}
 				}
-				currentQuery.Reset()
 			}
 			continue
 		}
@@ -262,12 +374,14 @@ func startShellWithContext(ctx *shellContext) error {
 			cmd := strings.ToLower(strings.TrimSpace(line))
 			switch {
 			case cmd == ".quit" || cmd == ".exit" || cmd == ".q":
-				fmt.Println("Goodbye!")
+				if ctx.interactive {
+					fmt.Println("Goodbye!")
+				}
 				return nil
 			case cmd == ".help" || cmd == ".h":
 				printHelp()
 			case cmd == ".examples" || cmd == ".ex":
-				printExamples()
+				ctx.showExamples()
 			case cmd == ".clear" || cmd == ".c":
 				currentQuery.Reset()
 				fmt.Println("Query cleared.")
@@ -308,14 +422,15 @@ func startShellWithContext(ctx *shellContext) error {
 				}
 				
 				// Query all tables - API will filter to show only nameserver-specific tables
-				executeQuery(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID,
-					"SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+				ctx.executeQuery("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
 				
 				if err != nil {
-					fmt.Printf("\nNote: Could not list nameservers: %v\n", err)
+					fmt.Printf("\nNote: Could not list nameservers: %v\n", checkSession(err))
 				} else if len(databasesResponse.Databases) == 0 {
 					fmt.Println("\nNote: No nameservers found. Create one with: .create_ns <name>")
 				}
+			case cmd == ".tables --all" || cmd == ".systables":
+				ctx.showAllTables()
 				case cmd == ".nameservers" || cmd == ".ns":
 				// List available nameservers for context
 				databasesResponse, err := ctx.client.ListDatabases(ctx.accessToken, ctx.projectID, ctx.serverID)
@@ -355,7 +470,7 @@ func startShellWithContext(ctx *shellContext) error {
 						}
 						fmt.Println()
 						fmt.Println("Note: Inactive nameservers can prevent creating new ones with the same name.")
-						fmt.Println("      The system will reactivate them if you try to create a duplicate.")
+						fmt.Println("      Run 'flux-relay ns reactivate <name>' to make one active again.")
 						fmt.Println()
 					}
 					
@@ -371,35 +486,195 @@ func startShellWithContext(ctx *shellContext) error {
 					fmt.Println("  .use <nameserver>  - Switch to a nameserver context")
 					fmt.Println("  .create_ns <name>  - Create a new nameserver")
 				} else {
-					fmt.Printf("Error listing nameservers: %v\n", err)
+					fmt.Printf("Error listing nameservers: %v\n", checkSession(err))
 				}
-			case strings.HasPrefix(cmd, ".use"):
+			case strings.HasPrefix(cmd, ".indexes"):
 				parts := strings.Fields(cmd)
+				if len(parts) != 2 {
+					fmt.Println("Usage: .indexes <table_name>")
+					if ctx.nameserverName != "" {
+						fmt.Printf("Example: .indexes conversations_%s\n", ctx.nameserverName)
+					}
+					break
+				}
+				tableName := parts[1]
+				if !tableNamePattern.MatchString(tableName) {
+					fmt.Printf("Invalid table name: %s\n", tableName)
+					break
+				}
+				// One row per indexed column, using the table-valued forms of
+				// PRAGMA index_list and PRAGMA index_info
+				ctx.executeQuery(fmt.Sprintf(
+					"SELECT il.name AS index_name, il.\"unique\" AS is_unique, il.origin AS origin, ii.seqno AS position, ii.name AS column_name "+
+						"FROM pragma_index_list('%s') AS il JOIN pragma_index_info(il.name) AS ii "+
+						"ORDER BY il.name, ii.seqno", tableName))
+			case strings.HasPrefix(cmd, ".explain"):
+				// Use the original line so string literals keep their case
+				query := strings.TrimSpace(line[len(".explain"):])
+				query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+				if query == "" {
+					fmt.Println("Usage: .explain <select query>")
+					fmt.Println("Example: .explain SELECT * FROM messages_name1 WHERE server_id = ? AND conversation_id = 'c1'")
+					break
+				}
+				explained, err := explainQuery(ctx.prepareQuery(query))
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					break
+				}
+				ctx.executeQuery(explained)
+			case strings.HasPrefix(cmd, ".autosuffix"):
+				parts := strings.Fields(cmd)
+				if len(parts) == 1 {
+					status := "off"
+					if ctx.autoSuffix {
+						status = "on"
+					}
+					fmt.Printf("autosuffix is %s\n", status)
+					break
+				}
+				switch parts[1] {
+				case "on":
+					if ctx.nameserverName == "" {
+						fmt.Println("No nameserver selected. Use .use <nameserver> before enabling autosuffix.")
+						break
+					}
+					ctx.autoSuffix = true
+					fmt.Println(ui.Success("autosuffix on: table names will be rewritten to use suffix _%s", ctx.nameserverName))
+					fmt.Printf("   Example: conversations → conversations_%s\n", ctx.nameserverName)
+				case "off":
+					ctx.autoSuffix = false
+					fmt.Println("autosuffix off")
+				default:
+					fmt.Println("Usage: .autosuffix [on|off]")
+				}
+			case strings.HasPrefix(cmd, ".pager"):
+				parts := strings.Fields(cmd)
+				if len(parts) == 1 {
+					status := "off"
+					if ctx.pager {
+						status = "on"
+					}
+					fmt.Printf("pager is %s (%s)\n", status, strings.Join(pagerCommand(), " "))
+					break
+				}
+				switch parts[1] {
+				case "on":
+					ctx.pager = true
+					fmt.Println("pager on: results taller than the terminal are shown through $PAGER")
+				case "off":
+					ctx.pager = false
+					fmt.Println("pager off")
+				default:
+					fmt.Println("Usage: .pager [on|off]")
+				}
+			case strings.HasPrefix(cmd, ".headers"):
+				parts := strings.Fields(cmd)
+				if len(parts) == 1 {
+					status := "on"
+					if noHeader {
+						status = "off"
+					}
+					fmt.Printf("headers is %s\n", status)
+					break
+				}
+				switch parts[1] {
+				case "on":
+					noHeader = false
+					fmt.Println("headers on")
+				case "off":
+					noHeader = true
+					fmt.Println("headers off: results are shown without column names")
+				default:
+					fmt.Println("Usage: .headers [on|off]")
+				}
+			case strings.HasPrefix(cmd, ".numfmt"):
+				parts := strings.Fields(cmd)
+				if len(parts) == 1 {
+					status := "off"
+					if formatNumbers {
+						status = "on"
+					}
+					fmt.Printf("numfmt is %s\n", status)
+					break
+				}
+				switch parts[1] {
+				case "on":
+					formatNumbers = true
+					fmt.Printf("numfmt on: numbers are shown like %s\n", render.FormatNumber(1234567.5))
+				case "off":
+					formatNumbers = false
+					fmt.Println("numfmt off")
+				default:
+					fmt.Println("Usage: .numfmt [on|off]")
+				}
+			case cmd == ".timeout" || strings.HasPrefix(cmd, ".timeout "):
+				parts := strings.Fields(cmd)
+				if len(parts) == 1 {
+					if ctx.queryTimeout == 0 {
+						fmt.Printf("timeout is %s (default)\n", api.DefaultTimeout)
+					} else {
+						fmt.Printf("timeout is %s\n", ctx.queryTimeout)
+					}
+					break
+				}
+				if parts[1] == "default" {
+					ctx.queryTimeout = 0
+					ctx.queryClient = nil
+					fmt.Printf("timeout %s (default)\n", api.DefaultTimeout)
+					break
+				}
+				timeout, err := time.ParseDuration(parts[1])
+				if err != nil || timeout <= 0 {
+					fmt.Println("Usage: .timeout [duration|default], e.g. .timeout 5m")
+					break
+				}
+				ctx.queryTimeout = timeout
+				ctx.queryClient = api.NewClientWithTimeout(ctx.client.BaseURL, timeout)
+				fmt.Printf("timeout %s: queries give up after %s\n", timeout, timeout)
+			case cmd == ".tz" || strings.HasPrefix(cmd, ".tz "):
+				// Use the original line so zone names keep their case
+				parts := strings.Fields(line)
+				if len(parts) == 1 {
+					if displayTimeZone == nil {
+						fmt.Println("tz is off: timestamps are shown as stored (UTC)")
+					} else {
+						fmt.Printf("tz is %s\n", displayTimeZone)
+					}
+					break
+				}
+				if strings.EqualFold(parts[1], "off") {
+					displayTimeZone = nil
+					fmt.Println("tz off")
+					break
+				}
+				loc, err := render.LoadTimeZone(parts[1])
+				if err != nil {
+					fmt.Printf("Error: unknown time zone %q. Use local, UTC or a name like Europe/Berlin\n", parts[1])
+					break
+				}
+				displayTimeZone = loc
+				fmt.Printf("tz %s: timestamps are shown like %s\n", loc, time.Now().In(loc).Format("2006-01-02 15:04:05 MST"))
+			case strings.HasPrefix(cmd, ".use"):
+				// Use the original line so IDs keep their case
+				parts := strings.Fields(line)
 				if len(parts) > 1 {
 					nameserverName := parts[1]
 					// Find nameserver
-					databasesResponse, err := ctx.client.ListDatabases(ctx.accessToken, ctx.projectID, ctx.serverID)
+					found, err := resolveNameserver(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID, nameserverName)
 					if err != nil {
 						fmt.Printf("Error: %v\n", err)
-						break
-					}
-					
-					var found *api.Database
-					for i := range databasesResponse.Databases {
-						db := &databasesResponse.Databases[i]
-						if db.DatabaseName == nameserverName || db.ID == nameserverName {
-							found = db
-							break
-						}
-					}
-					
-					if found == nil {
-						fmt.Printf("Nameserver '%s' not found. Use .nameservers to see available nameservers.\n", nameserverName)
+						fmt.Println("Use .nameservers to see available nameservers.")
 					} else {
 						ctx.nameserverID = found.ID
 						ctx.nameserverName = found.DatabaseName
-						fmt.Printf("✅ Switched to nameserver: %s\n", found.DatabaseName)
+						fmt.Println(ui.Success("Switched to nameserver: %s", found.DatabaseName))
 						fmt.Printf("   Tables will use suffix: conversations_%s\n", found.DatabaseName)
+
+						// Keep the top-level selection in sync with the shell
+						if err := ctx.cfg.SetSelectedNameserver(found.ID); err != nil {
+							fmt.Println(ui.Warn("Could not save nameserver selection: %v", err))
+						}
 					}
 				} else {
 					if ctx.nameserverName != "" {
@@ -408,6 +683,32 @@ func startShellWithContext(ctx *shellContext) error {
 						fmt.Println("No nameserver selected. Use .use <nameserver> to select one.")
 					}
 				}
+			case cmd == ".server" || cmd == ".connect" || strings.HasPrefix(cmd, ".server ") || strings.HasPrefix(cmd, ".connect "):
+				// Use the original line so IDs keep their case
+				parts := strings.Fields(line)
+				if len(parts) < 2 {
+					fmt.Printf("Current server: %s (%s)\n", ctx.serverName, ctx.serverID)
+					fmt.Println("Use .server <name-or-id> to switch servers.")
+					break
+				}
+				server, err := resolveServer(ctx.client, ctx.accessToken, ctx.projectID, parts[1])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					break
+				}
+				ctx.serverID = server.ID
+				ctx.serverName = server.Name
+				ctx.nameserverID = ""
+				ctx.nameserverName = ""
+				fmt.Println(ui.Success("Connected to server: %s", server.Name))
+				fmt.Println()
+				ctx.printContextBanner()
+
+				// Keep the top-level selection in sync with the shell; this
+				// also clears the selected nameserver
+				if err := ctx.cfg.SetSelectedServer(server.ID); err != nil {
+					fmt.Println(ui.Warn("Could not save server selection: %v", err))
+				}
 			case strings.HasPrefix(cmd, ".create_ns") || strings.HasPrefix(cmd, ".create_nameserver"):
 				parts := strings.Fields(cmd)
 				if len(parts) > 1 {
@@ -417,6 +718,10 @@ func startShellWithContext(ctx *shellContext) error {
 						fmt.Println("Example: .create_ns db2")
 						break
 					}
+					if err := validateNameserverName(nameserverName); err != nil {
+						fmt.Printf("Error: %v\n", err)
+						break
+					}
 					
 					// First, check existing nameservers to help debug conflicts
 					databasesResponse, listErr := ctx.client.ListDatabases(ctx.accessToken, ctx.projectID, ctx.serverID)
@@ -428,17 +733,17 @@ func startShellWithContext(ctx *shellContext) error {
 								if db.DatabaseName == nameserverName {
 									// Exact match
 									if db.IsActive {
-										fmt.Printf("⚠️  Nameserver '%s' already exists and is active.\n", db.DatabaseName)
+										fmt.Println(ui.Warn("Nameserver '%s' already exists and is active.", db.DatabaseName))
 										fmt.Printf("   ID: %s\n", db.ID)
 										fmt.Println()
 										fmt.Println("Use .use " + db.DatabaseName + " to switch to it.")
 									} else {
-										fmt.Printf("⚠️  Found inactive nameserver '%s' - will be reactivated.\n", db.DatabaseName)
+										fmt.Println(ui.Warn("Found inactive nameserver '%s' - will be reactivated.", db.DatabaseName))
 										fmt.Printf("   ID: %s\n", db.ID)
 									}
 								} else {
 									// Case-insensitive match but different case
-									fmt.Printf("⚠️  Conflict: A nameserver with a similar name already exists:\n")
+									fmt.Println(ui.Warn("Conflict: A nameserver with a similar name already exists:"))
 									fmt.Printf("   Requested: '%s'\n", nameserverName)
 									fmt.Printf("   Existing:  '%s' (ID: %s)\n", db.DatabaseName, db.ID)
 									fmt.Println()
@@ -452,6 +757,10 @@ func startShellWithContext(ctx *shellContext) error {
 					
 					fmt.Printf("Creating nameserver '%s'...\n", nameserverName)
 					response, err := ctx.client.CreateNameserver(ctx.accessToken, ctx.projectID, ctx.serverID, nameserverName)
+					if api.IsUnauthorized(err) {
+						printSessionExpired()
+						break
+					}
 					if err != nil {
 						if apiErr, ok := err.(*api.APIError); ok {
 							errorMsg := apiErr.Error()
@@ -460,7 +769,7 @@ func startShellWithContext(ctx *shellContext) error {
 							
 							// Check if error suggests an inactive nameserver exists
 							if strings.Contains(errorMsg, "already exists") {
-								fmt.Println("💡 This error usually means:")
+								fmt.Println(ui.Hint("This error usually means:"))
 								fmt.Println("   1. An active nameserver with this name exists, OR")
 								fmt.Println("   2. An inactive (soft-deleted) nameserver exists and should be reactivated")
 								fmt.Println()
@@ -469,8 +778,9 @@ func startShellWithContext(ctx *shellContext) error {
 								fmt.Println()
 								
 								// Try to query directly for the nameserver using the API
-								fmt.Println("💡 Troubleshooting tips:")
-								fmt.Println("   - The API should automatically reactivate inactive nameservers")
+								fmt.Println(ui.Hint("Troubleshooting tips:"))
+								fmt.Println("   - Run 'flux-relay ns list --filter inactive' and reactivate a match")
+								fmt.Println("     with 'flux-relay ns reactivate <name>'")
 								fmt.Println("   - If this error persists, there may be an active nameserver")
 								fmt.Println("     with this name that's not visible in .nameservers")
 								fmt.Println("   - Try using a different name, or contact support if needed")
@@ -498,7 +808,7 @@ func startShellWithContext(ctx *shellContext) error {
 					// Check if it was reactivated
 					if response.Database.ID != "" {
 						// Check if this was a reactivation by looking at creation time
-						fmt.Printf("✅ Nameserver '%s' created successfully!\n", response.Database.DatabaseName)
+						fmt.Println(ui.Success("Nameserver '%s' created successfully!", response.Database.DatabaseName))
 						fmt.Printf("   ID: %s\n", response.Database.ID)
 						fmt.Println()
 						fmt.Println("Next steps:")
@@ -515,33 +825,20 @@ func startShellWithContext(ctx *shellContext) error {
 					fmt.Println("Use .nameservers to see existing nameservers first.")
 				}
 			case strings.HasPrefix(cmd, ".init_ns") || strings.HasPrefix(cmd, ".init_nameserver") || strings.HasPrefix(cmd, ".initialize"):
-				parts := strings.Fields(cmd)
+				// Use the original line so IDs keep their case
+				parts := strings.Fields(line)
 				var nameserverID string
 				var nameserverName string
 				
 				if len(parts) > 1 {
 					nameserverIdentifier := strings.Join(parts[1:], " ")
 					// Find nameserver
-					databasesResponse, err := ctx.client.ListDatabases(ctx.accessToken, ctx.projectID, ctx.serverID)
+					found, err := resolveNameserver(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID, nameserverIdentifier)
 					if err != nil {
 						fmt.Printf("Error: %v\n", err)
 						break
 					}
 					
-					var found *api.Database
-					for i := range databasesResponse.Databases {
-						db := &databasesResponse.Databases[i]
-						if db.DatabaseName == nameserverIdentifier || db.ID == nameserverIdentifier {
-							found = db
-							break
-						}
-					}
-					
-					if found == nil {
-						fmt.Printf("Nameserver '%s' not found.\n", nameserverIdentifier)
-						break
-					}
-					
 					nameserverID = found.ID
 					nameserverName = found.DatabaseName
 				} else if ctx.nameserverID != "" {
@@ -557,6 +854,10 @@ func startShellWithContext(ctx *shellContext) error {
 				
 				fmt.Printf("Initializing schema for nameserver '%s'...\n", nameserverName)
 				response, err := ctx.client.InitializeNameserver(ctx.accessToken, ctx.projectID, ctx.serverID, nameserverID)
+				if api.IsUnauthorized(err) {
+					printSessionExpired()
+					break
+				}
 				if err != nil {
 					if apiErr, ok := err.(*api.APIError); ok {
 						fmt.Printf("Error: %s\n", apiErr.Error())
@@ -566,7 +867,8 @@ func startShellWithContext(ctx *shellContext) error {
 					break
 				}
 				
-				fmt.Printf("✅ Schema initialized for '%s'!\n", nameserverName)
+				rememberSchemaType(ctx.cfg, nameserverID, "messaging", response)
+				fmt.Println(ui.Success("Schema initialized for '%s'!", nameserverName))
 				if response.TablesCreated > 0 {
 					fmt.Printf("   Created %d tables\n", response.TablesCreated)
 				}
@@ -590,14 +892,106 @@ func startShellWithContext(ctx *shellContext) error {
 				fmt.Println("Or create custom tables manually:")
 				fmt.Printf("  CREATE TABLE custom_table_%s (id TEXT PRIMARY KEY, server_id TEXT, data TEXT);\n", nameserverName)
 			case strings.HasPrefix(cmd, ".schema"):
-				parts := strings.Fields(cmd)
-				if len(parts) > 1 {
+				// Use the original line so table names keep their case
+				parts := strings.Fields(line)
+				switch {
+				case len(parts) == 1:
+					// All tables, or only the current nameserver's after .use
+					ctx.showSchema(ctx.nameserverName)
+				case len(parts) == 3 && parts[1] == "--ns":
+					ctx.showSchema(parts[2])
+				case len(parts) == 2 && !strings.HasPrefix(parts[1], "--"):
 					tableName := parts[1]
-					executeQuery(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID,
-						fmt.Sprintf("SELECT sql FROM sqlite_master WHERE type='table' AND name = '%s'", tableName))
+					if !tableNamePattern.MatchString(tableName) {
+						fmt.Printf("Invalid table name: %s\n", tableName)
+						break
+					}
+					ctx.executeQuery(fmt.Sprintf("SELECT sql FROM sqlite_master WHERE type='table' AND name = '%s'", tableName))
+				default:
+					fmt.Println("Usage: .schema [table_name | --ns <nameserver>]")
+				}
+			case cmd == ".stats":
+				ctx.showStats()
+			case cmd == ".snippets":
+				snippets, err := loadSnippets()
+				if err != nil {
+					fmt.Printf("Error: could not read snippets: %v\n", err)
+					break
+				}
+				if len(snippets) == 0 {
+					fmt.Println("No saved snippets. Run a query, then .save <name> to save it.")
+					break
+				}
+				for _, name := range snippetNames(snippets) {
+					fmt.Printf("  %s: %s\n", name, snippets[name])
+				}
+			case cmd == ".save" || strings.HasPrefix(cmd, ".save "):
+				// Use the original line; snippet names are case-sensitive
+				parts := strings.Fields(line)
+				if len(parts) != 2 {
+					fmt.Println("Usage: .save <name>")
+					break
+				}
+				if ctx.lastQuery == "" {
+					fmt.Println("No query to save yet. Run one first.")
+					break
+				}
+				replaced, err := saveSnippet(parts[1], ctx.lastQuery)
+				if err != nil {
+					fmt.Printf("Error: could not save snippet: %v\n", err)
+					break
+				}
+				if replaced {
+					fmt.Println(ui.Success("Replaced snippet '%s'", parts[1]))
 				} else {
-					fmt.Println("Usage: .schema <table_name>")
+					fmt.Println(ui.Success("Saved snippet '%s'", parts[1]))
+				}
+			case cmd == ".load" || strings.HasPrefix(cmd, ".load ") || cmd == ".run" || strings.HasPrefix(cmd, ".run "):
+				parts := strings.Fields(line)
+				if len(parts) != 2 {
+					fmt.Printf("Usage: %s <name>\n", strings.Fields(cmd)[0])
+					break
 				}
+				snippets, err := loadSnippets()
+				if err != nil {
+					fmt.Printf("Error: could not read snippets: %v\n", err)
+					break
+				}
+				query, ok := snippets[parts[1]]
+				if !ok {
+					fmt.Printf("No snippet named '%s'. Use .snippets to list them.\n", parts[1])
+					break
+				}
+				if strings.HasPrefix(cmd, ".run") {
+					currentQuery.Reset()
+					if err := runQuery(query); err != nil {
+						return err
+					}
+					continue
+				}
+				// Make it the pending query, so Enter runs it and further
+				// lines extend it
+				fmt.Println(query)
+				if ctx.interactive {
+					fmt.Println("(press Enter to run it, or keep typing to extend it)")
+				}
+				currentQuery.Reset()
+				currentQuery.WriteString(query)
+				continue
+			case cmd == ".paste":
+				query, err := clipboardQuery()
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					break
+				}
+				// Like .load, so the query can be checked before Enter runs it
+				fmt.Println(query)
+				if ctx.interactive {
+					fmt.Println("(press Enter to run it, or keep typing to extend it)")
+				}
+				currentQuery.Reset()
+				currentQuery.WriteString(query)
+				continue
 			case strings.HasPrefix(cmd, ".create_table") || strings.HasPrefix(cmd, ".create"):
 				// Helper for creating tables - shows example
 				parts := strings.Fields(cmd)
@@ -634,7 +1028,21 @@ func startShellWithContext(ctx *shellContext) error {
 					}
 					fmt.Println()
 					fmt.Println("Note: Table names must follow the pattern: {baseName}_{nameserverName}")
-					fmt.Println("Example: conversations_name1, messages_name1, custom_table_db2, etc.")
+					var tables []string
+					if ctx.nameserverName != "" {
+						if messaging, found := ctx.schemaHelp(); !messaging {
+							tables = found
+						}
+					}
+					if len(tables) > 0 {
+						names := make([]string, len(tables))
+						for i, base := range tables {
+							names[i] = base + "_" + ctx.nameserverName
+						}
+						fmt.Printf("Existing tables: %s\n", strings.Join(names, ", "))
+					} else {
+						fmt.Println("Example: conversations_name1, messages_name1, custom_table_db2, etc.")
+					}
 					fmt.Println()
 					fmt.Println("Use .nameservers to see available nameserver names.")
 					fmt.Println("Use .use <nameserver> to set the context.")
@@ -647,8 +1055,7 @@ func startShellWithContext(ctx *shellContext) error {
 					fmt.Printf("  DROP TABLE %s;\n", tableName)
 					fmt.Println()
 					fmt.Println("Or execute directly:")
-					executeQuery(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID,
-						fmt.Sprintf("DROP TABLE %s", tableName))
+					ctx.executeQuery(fmt.Sprintf("DROP TABLE %s", tableName))
 				} else {
 					fmt.Println("Usage: .drop_table <table_name>")
 					if ctx.nameserverName != "" {
@@ -659,7 +1066,31 @@ func startShellWithContext(ctx *shellContext) error {
 				}
 			case strings.HasPrefix(cmd, ".alter_table") || strings.HasPrefix(cmd, ".alter"):
 				// Helper for altering tables - shows example
+				messaging, tables := true, []string(nil)
 				if ctx.nameserverName != "" {
+					messaging, tables = ctx.schemaHelp()
+				}
+				if ctx.nameserverName != "" && !messaging {
+					table := "my_table_" + ctx.nameserverName
+					if len(tables) > 0 {
+						table = tables[0] + "_" + ctx.nameserverName
+					}
+					fmt.Printf("Current nameserver: %s\n", ctx.nameserverName)
+					fmt.Println()
+					fmt.Println("Common schema customizations:")
+					fmt.Println()
+					fmt.Println("1. Add a column:")
+					fmt.Printf("   ALTER TABLE %s ADD COLUMN notes TEXT;\n", table)
+					fmt.Println()
+					fmt.Println("2. Rename a column (SQLite 3.25.0+):")
+					fmt.Printf("   ALTER TABLE %s RENAME COLUMN old_name TO new_name;\n", table)
+					fmt.Println()
+					fmt.Println("3. Create an index:")
+					fmt.Printf("   CREATE INDEX idx_%s_created_at ON %s(created_at);\n", table, table)
+					fmt.Println()
+					fmt.Println(ui.Warn("Note: SQLite doesn't support direct column type changes."))
+					fmt.Println("   To change a column type, create a new table, copy the rows and rename it.")
+				} else if ctx.nameserverName != "" {
 					fmt.Printf("Current nameserver: %s\n", ctx.nameserverName)
 					fmt.Println()
 					fmt.Println("Common schema customizations:")
@@ -698,7 +1129,7 @@ func startShellWithContext(ctx *shellContext) error {
 					fmt.Println("6. Create an index:")
 					fmt.Printf("   CREATE INDEX idx_conversations_%s_priority ON conversations_%s(priority);\n", ctx.nameserverName, ctx.nameserverName)
 					fmt.Println()
-					fmt.Println("⚠️  Note: SQLite doesn't support direct column type changes.")
+					fmt.Println(ui.Warn("Note: SQLite doesn't support direct column type changes."))
 					fmt.Println("   To change a column type, you need to recreate the table.")
 					fmt.Println("   See example #5 above for the process.")
 				} else {
@@ -711,6 +1142,7 @@ func startShellWithContext(ctx *shellContext) error {
 				fmt.Println()
 				fmt.Println("Note: You can only alter tables that belong to your server's nameservers.")
 				fmt.Println("      Use .schema <table> to see current table structure.")
+				fmt.Println("      Use .indexes <table> to see existing indexes.")
 			default:
 				fmt.Printf("Unknown command: %s\n", line)
 				fmt.Println("Type \".help\" for available commands.")
@@ -733,7 +1165,7 @@ func startShellWithContext(ctx *shellContext) error {
 			if strings.Contains(queryUpper, " LIMIT") && !strings.Contains(queryUpper, " LIMIT ") && !strings.HasSuffix(queryUpper, " LIMIT") {
 				// LIMIT with no number - check if it ends with just "LIMIT"
 				if strings.HasSuffix(strings.TrimSpace(queryUpper), "LIMIT") {
-					fmt.Println("⚠️  Incomplete query: LIMIT requires a number (e.g., LIMIT 10)")
+					fmt.Println(ui.Warn("Incomplete query: LIMIT requires a number (e.g., LIMIT 10)"))
 					fmt.Println("   Complete your query or type .clear to start over")
 					continue
 				}
@@ -762,130 +1194,414 @@ func startShellWithContext(ctx *shellContext) error {
 					// Check if LIMIT has a number after it
 					limitPattern := regexp.MustCompile(`LIMIT\s+(\d+)`)
 					if !limitPattern.MatchString(queryUpperCheck) && strings.HasSuffix(strings.TrimSpace(queryUpperCheck), "LIMIT") {
-						fmt.Println("⚠️  Error: LIMIT requires a number (e.g., LIMIT 10)")
+						fmt.Println(ui.Warn("Error: LIMIT requires a number (e.g., LIMIT 10)"))
 						fmt.Println("   Your query: " + query)
 						currentQuery.Reset()
 						continue
 					}
 				}
 
+				currentQuery.Reset()
 				if query != "" {
-					executeQuery(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID, query)
+					if err := runQuery(query); err != nil {
+						return err
+					}
 				}
-				currentQuery.Reset()
 			}
 	}
+}
+
+// executeQuery executes a SQL query in the shell's context, displays the
+// results and reports whether the query succeeded
+func (ctx *shellContext) executeQuery(query string) bool {
+	queryArgs := serverIDArgs(query, ctx.serverID)
+
+	client := ctx.client
+	if ctx.queryClient != nil {
+		client = ctx.queryClient
+	}
+
+	started := time.Now()
+	queryResponse, err := withLockRetry(query, func() (*api.QueryResponse, error) {
+		return client.ExecuteQuery(ctx.accessToken, ctx.projectID, ctx.serverID, query, queryArgs)
+	})
+	recordQuery("shell", ctx.projectID, ctx.serverID, ctx.nameserverID, query, started, queryResponse, err)
+	if api.IsUnauthorized(err) {
+		printSessionExpired()
+		return false
+	}
+	keepInsertID(query, queryResponse)
+	if err == nil && queryResponse.Success {
+		auditMutation(ctx.projectID, ctx.serverID, ctx.nameserverID, query, queryResponse)
+	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading input: %w", err)
+	// Render first so a long table can be shown through the pager
+	var output bytes.Buffer
+	if api.IsTimeout(err) {
+		err = queryTimeoutError(query, err, ctx.queryTimeout, true)
 	}
+	printQueryResult(&output, query, queryResponse, err)
+	showPaged(output.String(), ctx.pager)
 
-	return nil
+	return err == nil && (queryResponse.Success || queryResponse.ErrorMessage == "")
 }
 
-// executeQuery executes a SQL query and displays results
-func executeQuery(client *api.Client, accessToken, projectID, serverID, query string) {
-	queryArgs := []interface{}{}
+// showSchema prints the CREATE TABLE statement of every non-system table,
+// like sqlite3's bare .schema. With a nameserver name, only tables carrying
+// its suffix are shown.
+func (ctx *shellContext) showSchema(nameserverName string) {
+	query := "SELECT sql FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND sql IS NOT NULL"
+	if nameserverName != "" {
+		if !tableNamePattern.MatchString(nameserverName) {
+			fmt.Printf("Invalid nameserver name: %s\n", nameserverName)
+			return
+		}
+		query += " AND " + suffixedTableCondition(nameserverName)
+	}
+	query += " ORDER BY name"
 
-	queryResponse, err := client.ExecuteQuery(accessToken, projectID, serverID, query, queryArgs)
-	if err != nil {
-		if apiErr, ok := err.(*api.APIError); ok {
-			errorMsg := apiErr.Error()
-			fmt.Printf("Error: %s\n", errorMsg)
-			
-			// Provide helpful hints for common errors
-			if strings.Contains(errorMsg, "SQL_PARSE_ERROR") || strings.Contains(errorMsg, "unexpected end of input") {
-				fmt.Println()
-				fmt.Println("💡 Common causes:")
-				fmt.Println("  - Incomplete query (e.g., LIMIT without a number)")
-				fmt.Println("  - Missing semicolon or closing parenthesis")
-				fmt.Println("  - Typo in SQL syntax")
-				fmt.Println()
-				fmt.Println("Example: SELECT * FROM table WHERE server_id = ? LIMIT 10;")
-			} else if strings.Contains(errorMsg, "no such table") {
-				fmt.Println()
-				fmt.Println("💡 Make sure:")
-				fmt.Println("  - Table name includes nameserver suffix (e.g., conversations_name1)")
-				fmt.Println("  - Use .tables to see available tables")
-				fmt.Println("  - Use .nameservers to see nameserver names")
-			} else if strings.Contains(errorMsg, "server_id") {
-				fmt.Println()
-				fmt.Println("💡 Remember: All queries must include WHERE server_id = ?")
-			}
+	queryResponse, err := ctx.client.ExecuteQuery(ctx.accessToken, ctx.projectID, ctx.serverID, query, []interface{}{})
+	if api.IsUnauthorized(err) {
+		printSessionExpired()
+		return
+	}
+	if err != nil || !queryResponse.Success {
+		printQueryResult(os.Stdout, query, queryResponse, err)
+		return
+	}
+
+	if len(queryResponse.Rows) == 0 {
+		if nameserverName != "" {
+			fmt.Printf("No tables with suffix '_%s' found.\n", nameserverName)
 		} else {
-			fmt.Printf("Error: %v\n", err)
+			fmt.Println("No tables found.")
 		}
 		return
 	}
+	for _, row := range queryResponse.Rows {
+		if len(row) > 0 && row[0] != nil {
+			fmt.Printf("%s;\n", render.CellText(row[0]))
+		}
+	}
+}
 
-	// Check for errors - but also handle cases where Success might not be set but we have data
-	if !queryResponse.Success && queryResponse.ErrorMessage != "" {
-		fmt.Printf("Error: %s\n", queryResponse.ErrorMessage)
+// showAllTables lists every table and view, including SQLite's own and those
+// without a nameserver suffix, and says what each one is
+func (ctx *shellContext) showAllTables() {
+	query := "SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') ORDER BY name"
+	queryResponse, err := ctx.client.ExecuteQuery(ctx.accessToken, ctx.projectID, ctx.serverID, query, []interface{}{})
+	if api.IsUnauthorized(err) {
+		printSessionExpired()
 		return
 	}
-	
-	// If Success is false but no error message, and we have no data, it might be an empty result
-	if !queryResponse.Success && queryResponse.ErrorMessage == "" && len(queryResponse.Columns) == 0 && len(queryResponse.Rows) == 0 {
-		fmt.Println("Query returned no results.")
-		fmt.Println()
-		fmt.Println("This could mean:")
-		fmt.Println("  - No tables exist yet (initialize your nameserver schema)")
-		fmt.Println("  - Tables don't match the expected pattern")
-		fmt.Println("  - Use .nameservers to see available nameservers")
+	if err != nil || !queryResponse.Success {
+		printQueryResult(os.Stdout, query, queryResponse, err)
 		return
 	}
 
-	// Display results
-	if len(queryResponse.Columns) > 0 {
-		// SELECT query - display results in table
-		if len(queryResponse.Rows) == 0 {
-			fmt.Println("No rows returned.")
-			fmt.Println()
-			fmt.Println("Note: If you expected to see tables, make sure:")
-			fmt.Println("  1. Your nameserver has been initialized")
-			fmt.Println("  2. Tables follow the pattern: {baseName}_{nameserverName}")
-			fmt.Println("  3. Use .nameservers to see available nameservers")
+	// Inactive nameservers still own their tables
+	nameservers := map[string]bool{}
+	databasesResponse, nsErr := ctx.client.ListDatabases(ctx.accessToken, ctx.projectID, ctx.serverID)
+	if nsErr == nil {
+		for _, db := range databasesResponse.Databases {
+			nameservers[db.DatabaseName] = db.IsActive
+		}
+	}
+
+	// sqlite_master holds the schema but isn't listed in itself
+	rows := append([][]interface{}{{"sqlite_master", "table"}}, queryResponse.Rows...)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tKIND")
+	fmt.Fprintln(w, "──\t──\t──")
+	counts := map[string]int{}
+	for _, row := range rows {
+		if len(row) < 2 || row[0] == nil {
+			continue
+		}
+		name := render.CellText(row[0])
+		var kind string
+		if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+			kind = "system"
+			counts["system"]++
+		} else if ns, active, ok := tableNameserver(name, nameservers); ok {
+			kind = "nameserver " + ns
+			if !active {
+				kind += " (inactive)"
+			}
+			if ns == ctx.nameserverName {
+				kind += " ←"
+			}
+			counts["nameserver"]++
+		} else {
+			kind = "other (no nameserver suffix)"
+			counts["other"]++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, render.CellText(row[1]), kind)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Printf("%d system, %d nameserver, %d other\n", counts["system"], counts["nameserver"], counts["other"])
+	if nsErr != nil {
+		fmt.Printf("Note: Could not list nameservers, so no table is marked as theirs: %v\n", checkSession(nsErr))
+	}
+}
+
+// tableNameserver returns the nameserver whose suffix a table name carries,
+// preferring the longest match, and whether that nameserver is active
+func tableNameserver(table string, nameservers map[string]bool) (string, bool, bool) {
+	best := ""
+	for ns := range nameservers {
+		if len(ns) > len(best) && strings.HasSuffix(strings.ToLower(table), "_"+strings.ToLower(ns)) {
+			best = ns
+		}
+	}
+	if best == "" {
+		return "", false, false
+	}
+	return best, nameservers[best], true
+}
+
+// statsWorkers bounds how many COUNT(*) queries .stats runs at once
+const statsWorkers = 4
+
+// tableStat is the row count of one table for .stats
+type tableStat struct {
+	name       string
+	byServerID bool
+	rows       string
+	err        error
+}
+
+// schemaHelp tells the shell's help what to assume about the current
+// nameserver's tables: whether it has the messaging schema, from the schema
+// type saved when it was initialized or else from its tables, and the base
+// names of its tables when they had to be looked up. If they can't be, the
+// messaging schema is assumed as before.
+func (ctx *shellContext) schemaHelp() (messaging bool, tables []string) {
+	switch ctx.cfg.GetSchemaType(ctx.nameserverID) {
+	case "messaging", "both":
+		return true, nil
+	}
+
+	query := "SELECT name FROM sqlite_master WHERE type = 'table' AND " + suffixedTableCondition(ctx.nameserverName) + " ORDER BY name"
+	queryResponse, err := ctx.client.ExecuteQuery(ctx.accessToken, ctx.projectID, ctx.serverID, query, []interface{}{})
+	if err != nil || !queryResponse.Success {
+		return true, nil
+	}
+	for _, row := range queryResponse.Rows {
+		if len(row) == 0 || row[0] == nil {
+			continue
+		}
+		name := render.CellText(row[0])
+		if len(name) <= len(ctx.nameserverName)+1 {
+			continue
+		}
+		base := name[:len(name)-len(ctx.nameserverName)-1]
+		if strings.EqualFold(base, "conversations") {
+			messaging = true
+		}
+		tables = append(tables, base)
+	}
+	return messaging, tables
+}
+
+// showExamples prints example queries: the messaging schema's, unless the
+// current nameserver is known to have other tables
+func (ctx *shellContext) showExamples() {
+	if ctx.nameserverName != "" {
+		if messaging, tables := ctx.schemaHelp(); !messaging {
+			printTableExamples(ctx.nameserverName, tables)
 			return
 		}
+	}
+	printExamples()
+}
+
+// printTableExamples prints example queries for a nameserver without the
+// messaging schema, using its own tables by base name
+func printTableExamples(nameserverName string, tables []string) {
+	if len(tables) == 0 {
+		fmt.Printf("Nameserver '%s' has no tables yet.\n", nameserverName)
+		fmt.Println()
+		fmt.Printf("Initialize its schema with .init_ns %s, or create a table:\n", nameserverName)
+		fmt.Printf("  CREATE TABLE my_table_%s (id TEXT PRIMARY KEY, server_id TEXT NOT NULL, data TEXT);\n", nameserverName)
+		return
+	}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	table := tables[0] + "_" + nameserverName
+	fmt.Printf("Tables of nameserver '%s':\n", nameserverName)
+	for _, base := range tables {
+		fmt.Printf("  %s_%s\n", base, nameserverName)
+	}
+	fmt.Println()
+	fmt.Println("1. Show a table's schema:")
+	fmt.Printf("   .schema %s\n", table)
+	fmt.Println()
+	fmt.Println("2. List rows:")
+	fmt.Printf("   SELECT * FROM %s WHERE server_id = ? LIMIT 10;\n", table)
+	fmt.Println()
+	fmt.Println("3. Count rows:")
+	fmt.Printf("   SELECT COUNT(*) AS total FROM %s WHERE server_id = ?;\n", table)
+	fmt.Println()
+	fmt.Println("4. Add a column:")
+	fmt.Printf("   ALTER TABLE %s ADD COLUMN notes TEXT;\n", table)
+	fmt.Println()
+	fmt.Println("5. Create a custom table:")
+	fmt.Printf("   CREATE TABLE my_table_%s (id TEXT PRIMARY KEY, server_id TEXT NOT NULL, data TEXT);\n", nameserverName)
+}
 
-		// Print header
-		fmt.Fprintln(w, strings.Join(queryResponse.Columns, "\t"))
+// showStats prints the row count of every table of the current nameserver.
+// Tables with a server_id column only count this server's rows.
+func (ctx *shellContext) showStats() {
+	if ctx.nameserverName == "" {
+		fmt.Println("No nameserver selected. Use .use <nameserver> first.")
+		return
+	}
 
-		// Print separator
-		separator := make([]string, len(queryResponse.Columns))
-		for i := range separator {
-			separator[i] = "──"
+	query := "SELECT m.name, EXISTS (SELECT 1 FROM pragma_table_info(m.name) WHERE name = 'server_id') AS has_server_id " +
+		"FROM sqlite_master AS m WHERE m.type = 'table' AND m." + suffixedTableCondition(ctx.nameserverName) + " ORDER BY m.name"
+	queryResponse, err := ctx.client.ExecuteQuery(ctx.accessToken, ctx.projectID, ctx.serverID, query, []interface{}{})
+	if api.IsUnauthorized(err) {
+		printSessionExpired()
+		return
+	}
+	if err != nil || !queryResponse.Success {
+		printQueryResult(os.Stdout, query, queryResponse, err)
+		return
+	}
+	if len(queryResponse.Rows) == 0 {
+		fmt.Printf("No tables with suffix '_%s' found.\n", ctx.nameserverName)
+		return
+	}
+
+	stats := make([]tableStat, 0, len(queryResponse.Rows))
+	for _, row := range queryResponse.Rows {
+		if len(row) < 2 || row[0] == nil {
+			continue
 		}
-		fmt.Fprintln(w, strings.Join(separator, "\t"))
-
-		// Print rows
-		for _, row := range queryResponse.Rows {
-			rowStr := make([]string, len(row))
-			for i, val := range row {
-				if val == nil {
-					rowStr[i] = "NULL"
-				} else {
-					// Convert to string, handling JSON encoding for complex types
-					if str, ok := val.(string); ok {
-						rowStr[i] = str
-					} else {
-						jsonBytes, _ := json.Marshal(val)
-						rowStr[i] = string(jsonBytes)
-					}
-				}
+		stats = append(stats, tableStat{name: render.CellText(row[0]), byServerID: render.CellText(row[1]) == "1"})
+	}
+
+	// Count the tables in parallel with a bounded number of workers
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < statsWorkers && w < len(stats); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stats[i].rows, stats[i].err = ctx.countRows(stats[i].name, stats[i].byServerID)
 			}
-			fmt.Fprintln(w, strings.Join(rowStr, "\t"))
+		}()
+	}
+	for i := range stats {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS\tSCOPE")
+	fmt.Fprintln(w, "──\t──\t──")
+	expired := false
+	for _, stat := range stats {
+		scope := "whole table"
+		if stat.byServerID {
+			scope = "this server"
 		}
+		rows := stat.rows
+		if stat.err != nil {
+			expired = expired || api.IsUnauthorized(stat.err)
+			rows = "error: " + stat.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", stat.name, rows, scope)
+	}
+	w.Flush()
 
-		w.Flush()
-		fmt.Println()
-		fmt.Printf("Rows returned: %d (%dms)\n", len(queryResponse.Rows), queryResponse.ExecutionTime)
-	} else {
-		// INSERT/UPDATE/DELETE query
-		fmt.Printf("Query executed successfully (%dms)\n", queryResponse.ExecutionTime)
-		fmt.Printf("Rows affected: %d\n", queryResponse.RowsAffected)
+	if expired {
+		printSessionExpired()
+	}
+}
+
+// countRows returns the number of rows in a table, limited to the current
+// server when byServerID is set
+func (ctx *shellContext) countRows(tableName string, byServerID bool) (string, error) {
+	count, err := countTableRows(ctx.client, ctx.accessToken, ctx.projectID, ctx.serverID, tableName, byServerID, 0)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(count, 10), nil
+}
+
+// reloadToken switches to the saved access token if it changed since the
+// shell started, e.g. after 'flux-relay login' in another terminal
+func (ctx *shellContext) reloadToken() {
+	if token := ctx.cfg.GetAccessToken(); token != "" {
+		ctx.accessToken = token
+	}
+}
+
+// printSessionExpired explains how to recover from an expired session
+// without leaving the shell
+func printSessionExpired() {
+	fmt.Println(ui.Warn("Your session has expired."))
+	fmt.Println("   Run 'flux-relay login' in another terminal; the shell picks up the new")
+	fmt.Println("   token with your next command, so you don't need to leave it.")
+}
+
+// printQueryResult renders the result of a shell query, or its error, to out
+func printQueryResult(out io.Writer, query string, queryResponse *api.QueryResponse, err error) {
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok {
+			errorMsg := apiErr.Error()
+			fmt.Fprintf(out, "Error: %s\n", errorMsg)
+			printQueryErrorHint(out, errorMsg)
+		} else {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+		return
+	}
+
+	// The API client already settled Success: an errorMessage means failure,
+	// and an empty result is a success
+	if !queryResponse.Success && queryResponse.ErrorMessage != "" {
+		fmt.Fprintf(out, "Error: %s\n", queryResponse.ErrorMessage)
+		printQueryErrorHint(out, queryResponse.ErrorMessage)
+		return
+	}
+
+	// The shell always shows tables, whatever -o says
+	opts := renderOptions()
+	opts.Format = render.FormatTable
+	opts.ShowTypes = false
+	opts.Kind = resultKind(query)
+	if err := render.RenderQueryResponse(out, queryResponse, opts); err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return
+	}
+
+	if opts.Kind.ReturnsRows(queryResponse) && !opts.Kind.AffectsRows(queryResponse) && len(queryResponse.Rows) == 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Note: If you expected to see tables, make sure:")
+		fmt.Fprintln(out, "  1. Your nameserver has been initialized")
+		fmt.Fprintln(out, "  2. Tables follow the pattern: {baseName}_{nameserverName}")
+		fmt.Fprintln(out, "  3. Use .nameservers to see available nameservers")
+	}
+}
+
+// printQueryErrorHint prints the shell's advice for a failed query's error
+// message, if there is any
+func printQueryErrorHint(out io.Writer, message string) {
+	hint := queryErrorHint(message, true)
+	if hint == nil {
+		return
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, ui.Hint("%s", hint[0]))
+	for _, line := range hint[1:] {
+		fmt.Fprintln(out, line)
 	}
 }
 
@@ -898,9 +1614,26 @@ func printHelp() {
 	fmt.Println("  .clear, .c            Clear the current query")
 	fmt.Println("  .context, .ctx        Show current context (server/nameserver)")
 	fmt.Println("  .tables               List all tables")
-	fmt.Println("  .schema <table>       Show schema for a table")
+	fmt.Println("  .tables --all         Also list system and unsuffixed tables, marking each kind (alias .systables)")
+	fmt.Println("  .schema [table]       Show schema for a table, or all tables (current nameserver after .use)")
+	fmt.Println("  .schema --ns <name>   Show schema for all tables of a nameserver")
+	fmt.Println("  .pager [on|off]       Page long results through $PAGER (default: less -FRX)")
+	fmt.Println("  .headers [on|off]     Show column names above results (default: on)")
+	fmt.Println("  .numfmt [on|off]      Show numbers with thousands separators")
+	fmt.Println("  .tz [zone|off]        Show timestamps in a time zone (local, UTC, Europe/Berlin, ...)")
+	fmt.Println("  .timeout [dur|default] Give up on queries after this long (default: 30s)")
+	fmt.Println("  .indexes <table>      Show indexes and the columns they cover")
+	fmt.Println("  .stats                Show row counts of the current nameserver's tables")
+	fmt.Println("  .explain <query>      Show the query plan for a SELECT query")
+	fmt.Println("  .save <name>          Save the last query as a named snippet")
+	fmt.Println("  .load <name>          Recall a snippet as the pending query (Enter runs it)")
+	fmt.Println("  .run <name>           Run a snippet")
+	fmt.Println("  .snippets             List saved snippets")
+	fmt.Println("  .paste                Recall the clipboard as the pending query (Enter runs it)")
 	fmt.Println("  .nameservers, .ns     List available nameservers")
-	fmt.Println("  .use <nameserver>     Switch to a nameserver context")
+	fmt.Println("  .use <nameserver>     Switch to a nameserver context (saved as current selection)")
+	fmt.Println("  .server <name-or-id>  Switch to another server (alias .connect; saved as current selection)")
+	fmt.Println("  .autosuffix [on|off]  Append the nameserver suffix to table names automatically")
 	fmt.Println("  .create_ns <name>     Create a new nameserver")
 	fmt.Println("  .init_ns <name>       Initialize schema for a nameserver")
 	fmt.Println("  .drop_table <name>    Drop a table")
@@ -917,6 +1650,7 @@ func printHelp() {
 	fmt.Println("Table naming:")
 	fmt.Println("  Tables are named with nameserver suffix: conversations_{nameserver_name}")
 	fmt.Println("  Example: If nameserver is 'name1', use 'conversations_name1'")
+	fmt.Println("  Use .autosuffix on to write 'conversations' and have the suffix added for you")
 	fmt.Println("  Use .tables to see all available tables")
 	fmt.Println()
 	fmt.Println("Nameserver management:")
@@ -958,7 +1692,7 @@ func printExamples() {
 	fmt.Println("   SELECT id, created_at, title FROM conversations_name1 WHERE server_id = ? ORDER BY created_at DESC LIMIT 5;")
 	fmt.Println()
 	fmt.Println("6. Filter and search:")
-	fmt.Println("   SELECT * FROM conversations_name1 WHERE server_id = ? AND title LIKE '%search%' LIMIT 10;")
+	fmt.Printf("   SELECT * FROM conversations_name1 WHERE server_id = ? AND title LIKE '%%search%%' LIMIT 10;\n")
 	fmt.Println()
 	fmt.Println("7. Join tables (if you have related tables):")
 	fmt.Println("   SELECT c.id, c.title, COUNT(m.id) as message_count")
@@ -1143,7 +1877,7 @@ func printExamples() {
 	fmt.Println("    ORDER BY message_count DESC LIMIT 10;")
 	fmt.Println()
 	
-	fmt.Println("⚠️  IMPORTANT NOTES:")
+	fmt.Println(ui.Warn("IMPORTANT NOTES:"))
 	fmt.Println("───────────────────────────────────────────────────────────────")
 	fmt.Println("• Replace 'name1' with your actual nameserver name")
 	fmt.Println("• All queries must include 'WHERE server_id = ?' for data isolation")
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Open the SQL shell on the selected server and nameserver",
+	Long: `Open the interactive SQL shell in the current context: the selected
+project and server, and the selected nameserver if there is one.
+
+Select them first with 'flux-relay pr', 'flux-relay server' and
+'flux-relay ns use', or open a shell somewhere else with
+'flux-relay server shell <server>' or 'flux-relay ns shell <nameserver>'.
+
+Examples:
+  flux-relay shell`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := rejectSelectionOverrides(cmd.CommandPath()); err != nil {
+			return err
+		}
+		// A statement that fails in a piped script is already reported
+		cmd.SilenceUsage = true
+		return runServerShell("", "")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
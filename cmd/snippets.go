@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/postacksol/flux-relay-cli/internal/config"
+)
+
+// Snippets are named queries saved from the shell with .save and recalled
+// with .load or .run. Names are case-sensitive and shared by every project,
+// server and nameserver; with .autosuffix on, unsuffixed table names in a
+// snippet follow the current nameserver.
+
+// snippetsPath returns the location of the saved snippets
+func snippetsPath() string {
+	return filepath.Join(config.New().ConfigDir(), "snippets.json")
+}
+
+// loadSnippets returns the saved snippets by name. A missing file is no
+// snippets.
+func loadSnippets() (map[string]string, error) {
+	data, err := os.ReadFile(snippetsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return parseSnippets(data)
+}
+
+// parseSnippets decodes the contents of the snippets file; no data is no
+// snippets
+func parseSnippets(data []byte) (map[string]string, error) {
+	snippets := map[string]string{}
+	if len(data) == 0 {
+		return snippets, nil
+	}
+	if err := json.Unmarshal(data, &snippets); err != nil {
+		return nil, fmt.Errorf("%s is not valid JSON: %w", snippetsPath(), err)
+	}
+	return snippets, nil
+}
+
+// saveSnippet stores query under name, replacing a snippet of the same name.
+// It reports whether one was replaced. The file is rewritten under the config
+// lock, so shells saving snippets at the same time don't lose any.
+func saveSnippet(name, query string) (bool, error) {
+	replaced := false
+	err := config.New().UpdateStateFile("snippets.json", func(data []byte) ([]byte, error) {
+		snippets, err := parseSnippets(data)
+		if err != nil {
+			return nil, err
+		}
+		_, replaced = snippets[name]
+		snippets[name] = query
+		return json.MarshalIndent(snippets, "", "  ")
+	})
+	return replaced, err
+}
+
+// snippetNames returns the names of the saved snippets in order
+func snippetNames(snippets map[string]string) []string {
+	names := make([]string, 0, len(snippets))
+	for name := range snippets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
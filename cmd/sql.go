@@ -1,14 +1,23 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
-	"text/tabwriter"
+	"syscall"
+	"time"
 
 	"github.com/postacksol/flux-relay-cli/internal/api"
 	"github.com/postacksol/flux-relay-cli/internal/config"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -17,22 +26,279 @@ var sqlCmd = &cobra.Command{
 	Short: "Execute SQL query",
 	Long: `Execute SQL query on the selected server and nameserver.
 
-The query will automatically filter by server_id for data isolation.
+Each '?' in the query is bound to the selected server's ID, so
+'WHERE server_id = ?' limits a query to that server. Nothing is filtered
+for you: a query without a server_id condition sees every server's rows.
+With --params or --set, you supply all the values yourself.
 If a nameserver is selected, you can query nameserver-specific tables.
 
 Examples:
   flux-relay sql "SELECT * FROM conversations_db WHERE server_id = ? LIMIT 10"
   flux-relay sql "SELECT COUNT(*) FROM end_users_db WHERE server_id = ?"
-  flux-relay sql "INSERT INTO conversations_db (server_id, ...) VALUES (?, ...)"`,
-	Args: cobra.MinimumNArgs(1),
+  flux-relay sql "INSERT INTO conversations_db (server_id, ...) VALUES (?, ...)"
+  flux-relay sql --server OtherServer "SELECT COUNT(*) FROM end_users_db WHERE server_id = ?"
+  flux-relay sql -o json --json-objects "SELECT id, title FROM conversations_db WHERE server_id = ?"
+  flux-relay sql --params params.json "SELECT * FROM messages_db WHERE server_id = :server AND conversation_id = :conv"
+  flux-relay sql -f report.sql --set server=server_123 --set since=2024-01-01
+  flux-relay sql --clipboard
+  flux-relay sql --count-only "SELECT * FROM messages_db WHERE server_id = ? AND created_at > '2024-01-01'"
+  flux-relay sql --watch 5s "SELECT COUNT(*) FROM messages_db WHERE server_id = ?"
+  flux-relay sql --tz local "SELECT id, created_at FROM messages_db WHERE server_id = ? LIMIT 20"
+  flux-relay sql --max-rows 1000 "SELECT * FROM messages_db WHERE server_id = ?"
+  flux-relay sql --timeout 5m -f reports/monthly_activity.sql
+  flux-relay sql --explain "SELECT * FROM messages_db WHERE server_id = ? AND conversation_id = 'c1'"
+  flux-relay sql --dry-run -f migrations/002_add_index.sql
+  flux-relay sql --output-template '- [{{.title}}](/c/{{.id}})' "SELECT id, title FROM conversations_db WHERE server_id = ?"
+  flux-relay sql --transaction "ALTER TABLE t_ns RENAME TO t_old_ns; CREATE TABLE t_ns (...); INSERT INTO t_ns SELECT ... FROM t_old_ns; DROP TABLE t_old_ns"
+
+Named placeholders (:name) take their values from a JSON object --params
+file and from --set name=value, which wins over the file. Values are always
+bound as query arguments, never pasted into the SQL, so they can't change
+the statement. With --file (-f) the query is read from a file, which makes
+reusable report templates practical. --clipboard reads it from the system
+clipboard (pbpaste, wl-paste, xclip or xsel, or PowerShell on Windows), so a
+copied multi-line query needs no quoting.
+
+Without --max-rows every row is fetched. Giving --max-rows 0 explicitly says
+so too, with a note; when run interactively, a SELECT returning more than
+100,000 rows is counted first and you are asked before fetching them all.
+
+--output-template prints each row through a Go text/template instead of a
+table, e.g. to produce a markdown list or SQL. Columns are fields holding the
+value's text ({{.title}}, or {{index . "column name"}} for names that aren't
+identifiers), NULL is nil, and {{.__rownum}} is the row's number from 1.
+Each row ends with a newline. @file reads the template from a file.
+
+With --transaction the query may hold several statements separated by ';'.
+They are run between BEGIN and COMMIT, and a ROLLBACK is sent if any of them
+fails. If the API can't keep a transaction open across statements, nothing
+is run and an error is returned. --summary ends the output with a table of
+each statement's rows and server execution time, to find the slow step of a
+migration.
+
+--fail-on-empty and --fail-on-rows turn a SELECT into a check for scripts
+and CI. The result is printed as usual, then the exit status is:
+  0  the check passed
+  1  the query or command failed
+  2  the check failed (no rows with --fail-on-empty, any rows with --fail-on-rows)
+With --count-only the count is checked instead of the single result row.
+
+Ctrl+C while a query is running cancels the request, prints "query
+cancelled" and exits with status 130. A write may still be completed by the
+API once it has been sent.
+
+Each request gives up after 30 seconds. --timeout sets another limit for
+this run only, e.g. --timeout 5m for one heavy report. An expired request is
+cancelled like Ctrl+C cancels it, by closing the connection, so the same
+caveat applies to writes.
+
+--dry-run checks a query for CI without running it: each ';'-separated
+statement is compiled with EXPLAIN, so syntax errors and unknown tables or
+columns are reported but no data is read or changed. A statement using a
+table created earlier in the same script can't be checked and is reported
+as such, and an UPDATE or DELETE without server_id, which the real run
+refuses without --force, is listed as a warning. The exit status is 1 if any
+statement fails.
+
+A failed query's error is followed by the shell's hints about likely causes,
+such as a table name missing the nameserver suffix, when stderr is a
+terminal. --explain-schema-errors adds them in logs too, and
+--explain-schema-errors=false leaves them out.
+
+UPDATE and DELETE statements whose WHERE clause doesn't mention server_id
+are refused, since they could change other servers' rows or every row in
+the table. Pass --force to run them anyway.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case sqlFile != "" && sqlClipboard:
+			return fmt.Errorf("--file and --clipboard cannot be used together")
+		case sqlFile != "" || sqlClipboard:
+			if len(args) > 0 {
+				return fmt.Errorf("don't pass a query argument with --file or --clipboard")
+			}
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runSql,
 }
 
+var sqlExplain bool
+var sqlCountOnly bool
+var sqlShowTypes bool
+var sqlJSONObjects bool
+var sqlWatch time.Duration
+var sqlParamsFile string
+var sqlSets []string
+var sqlFile string
+var sqlClipboard bool
+var sqlTransaction bool
+var sqlFailOnEmpty bool
+var sqlFailOnRows bool
+var sqlMaxRows int
+var sqlTimeZone string
+var sqlQuiet bool
+var sqlTimeout time.Duration
+var sqlExplainErrors bool
+
 func init() {
+	sqlCmd.Flags().BoolVar(&sqlCountOnly, "count-only", false, "Print only the number of rows a SELECT query matches")
+	sqlCmd.Flags().BoolVar(&sqlExplain, "explain", false, "Show the SQLite query plan (EXPLAIN QUERY PLAN) instead of running the query")
+	sqlCmd.Flags().StringVar(&nullString, "null-string", nullString, "Text shown for NULL values (CSV default is an empty field)")
+	sqlCmd.Flags().StringVar(&emptyString, "empty-string", emptyString, "Text shown for empty strings in table output")
+	sqlCmd.Flags().DurationVar(&sqlWatch, "watch", 0, "Re-run the query at this interval (e.g. 5s) until Ctrl+C")
+	sqlCmd.Flags().StringVar(&sqlParamsFile, "params", "", "JSON file with query arguments: an array for '?' or an object for ':name' placeholders")
+	sqlCmd.Flags().StringArrayVar(&sqlSets, "set", nil, "Bind a ':name' placeholder to a text value, as name=value (repeatable)")
+	sqlCmd.Flags().StringVarP(&sqlFile, "file", "f", "", "Read the query from a file ('-' for stdin) instead of the arguments")
+	sqlCmd.Flags().BoolVar(&sqlClipboard, "clipboard", false, "Read the query from the system clipboard instead of the arguments")
+	sqlCmd.Flags().BoolVar(&sqlClipboard, "from-clipboard", false, "Alias for --clipboard")
+	sqlCmd.Flags().MarkHidden("from-clipboard")
+	sqlCmd.Flags().BoolVar(&noHeader, "no-header", false, "Leave out the column names and the line under them in table and CSV output")
+	sqlCmd.Flags().BoolVar(&sqlShowTypes, "show-types", false, "Show each column's inferred SQLite type under its name (table and CSV output)")
+	sqlCmd.Flags().BoolVar(&sqlTransaction, "transaction", false, "Run ';'-separated statements in one transaction, rolling back if any fails")
+	sqlCmd.Flags().BoolVar(&sqlSummary, "summary", false, "With --transaction, end with a table of each statement's rows and execution time")
+	sqlCmd.Flags().BoolVar(&formatNumbers, "format-numbers", false, "Add thousands separators to numbers in table output (uses LC_NUMERIC/LANG)")
+	sqlCmd.Flags().BoolVar(&sqlFailOnEmpty, "fail-on-empty", false, "Exit with status 2 if the SELECT returns no rows")
+	sqlCmd.Flags().BoolVar(&sqlFailOnRows, "fail-on-rows", false, "Exit with status 2 if the SELECT returns any rows")
+	sqlCmd.Flags().BoolVar(&sqlRetryOnLock, "retry-on-lock", false, "Retry INSERT/UPDATE/DELETE up to 5 times with backoff when the database is locked")
+	sqlCmd.Flags().BoolVar(&sqlDryRun, "dry-run", false, "Check that each statement parses and plans, without running anything")
+	sqlCmd.Flags().BoolVar(&sqlExplainErrors, "explain-schema-errors", false, "Add hints about likely causes to query errors, e.g. a missing nameserver suffix (default: on when stderr is a terminal)")
+	sqlCmd.Flags().BoolVar(&sqlForce, "force", false, "Run UPDATE and DELETE statements that have no server_id condition")
+	sqlCmd.Flags().BoolVarP(&sqlQuiet, "quiet", "q", false, "Print only the result table, without timing, row counts or notes (rows affected go to stderr)")
+	sqlCmd.Flags().DurationVar(&sqlTimeout, "timeout", 0, "Give up on each request after this long instead of 30s (e.g. 5m)")
+	sqlCmd.Flags().StringVar(&sqlTimeZone, "tz", "", "Show timestamps in this time zone in table output: local, UTC or a name like Europe/Berlin")
+	sqlCmd.Flags().IntVar(&sqlMaxRows, "max-rows", 0, "Show at most N rows and stop reading the result there (0 for no limit; given explicitly, asks before fetching over 100,000 rows)")
+	sqlCmd.Flags().BoolVar(&noPager, "no-pager", false, "Don't page long table output through $PAGER")
+	sqlCmd.Flags().StringVar(&sqlOutputTemplate, "output-template", "", "Print each row through a Go template, e.g. '{{.id}} - {{.title}}', or @file to read it from a file")
+	sqlCmd.Flags().BoolVar(&sqlJSONObjects, "json-objects", false, "With --output json or yaml, emit rows as column-keyed objects instead of arrays")
+	setOutputFormats(sqlCmd, render.FormatJSON, render.FormatYAML, render.FormatCSV)
 	rootCmd.AddCommand(sqlCmd)
 }
 
+// leadingKeyword returns the first keyword of a query in upper case, ignoring comments
+func leadingKeyword(query string) string {
+	for _, tok := range scanSQLTokens(query) {
+		if tok.word {
+			return strings.ToUpper(tok.text)
+		}
+		if tok.text != "(" {
+			break
+		}
+	}
+	return ""
+}
+
+// resultKind classifies a statement by its keywords, so its result is shown
+// as rows or as a change even when the API's columns say otherwise. A WITH
+// query is classified by the statement after its common table expressions.
+func resultKind(query string) render.ResultKind {
+	keyword := leadingKeyword(query)
+	returning := false
+	depth := 0
+	for _, tok := range scanSQLTokens(query) {
+		switch {
+		case tok.text == "(":
+			depth++
+		case tok.text == ")":
+			depth--
+		case depth > 0 || !tok.word:
+		case strings.EqualFold(tok.text, "RETURNING"):
+			returning = true
+		case keyword == "WITH":
+			switch word := strings.ToUpper(tok.text); word {
+			case "SELECT", "VALUES", "INSERT", "REPLACE", "UPDATE", "DELETE":
+				keyword = word
+			}
+		}
+	}
+
+	switch keyword {
+	case "SELECT", "VALUES", "PRAGMA", "EXPLAIN":
+		return render.ResultRows
+	case "INSERT", "REPLACE", "UPDATE", "DELETE":
+		if returning {
+			return render.ResultChangeReturning
+		}
+		return render.ResultChange
+	case "CREATE", "DROP", "ALTER":
+		return render.ResultChange
+	default:
+		return render.ResultUnknown
+	}
+}
+
+// explainQuery wraps a SELECT query in EXPLAIN QUERY PLAN
+func explainQuery(query string) (string, error) {
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH":
+		return "EXPLAIN QUERY PLAN " + query, nil
+	case "EXPLAIN":
+		return "", fmt.Errorf("query is already an EXPLAIN statement")
+	default:
+		return "", fmt.Errorf("only SELECT queries can be explained")
+	}
+}
+
+// countQuery wraps a SELECT query so it returns only its row count
+func countQuery(query string) (string, error) {
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH":
+	default:
+		return "", fmt.Errorf("--count-only only works with SELECT queries")
+	}
+
+	// A trailing semicolon is not allowed inside the subquery
+	tokens := scanSQLTokens(query)
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		query = query[:tokens[len(tokens)-1].start]
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	return "SELECT COUNT(*) FROM (" + strings.TrimSpace(query) + "\n)", nil
+}
+
 func runSql(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	if sqlMaxRows < 0 {
+		return fmt.Errorf("--max-rows must be 0 or more")
+	}
+	if cmd.Flags().Changed("timeout") && sqlTimeout <= 0 {
+		return fmt.Errorf("--timeout must be a positive duration, e.g. 90s or 5m")
+	}
+	if sqlSummary {
+		switch {
+		case !sqlTransaction:
+			return fmt.Errorf("--summary requires --transaction, which runs the statements one at a time")
+		case sqlDryRun:
+			return fmt.Errorf("--summary cannot be used with --dry-run, which runs nothing to time")
+		case outputFormat != "table":
+			return fmt.Errorf("--summary is for table output; JSON and YAML output already have each statement's executionTime")
+		}
+	}
+	if sqlOutputTemplate != "" {
+		if cmd.Flags().Changed("output") {
+			return fmt.Errorf("--output-template cannot be used with --output")
+		}
+		tmpl, err := parseOutputTemplate(sqlOutputTemplate)
+		if err != nil {
+			return err
+		}
+		outputTemplate = tmpl
+	}
+	// Hints are for a person at a terminal, not for scripts reading stderr
+	if !cmd.Flags().Changed("explain-schema-errors") {
+		sqlExplainErrors = isTerminal(os.Stderr)
+	}
+	if sqlTimeZone != "" {
+		loc, err := render.LoadTimeZone(sqlTimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid --tz: %w", err)
+		}
+		displayTimeZone = loc
+	}
+
 	// Get API URL
 	apiURL := getAPIURL()
 
@@ -43,100 +309,565 @@ func runSql(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	// Get selected project and server
-	projectID := cfg.GetSelectedProject()
-	if projectID == "" {
-		return fmt.Errorf("no project selected. Use 'flux-relay pr <project-name-or-id>' to select a project")
+	client := api.NewClient(apiURL)
+	if sqlTimeout > 0 {
+		client = api.NewClientWithTimeout(apiURL, sqlTimeout)
 	}
 
-	serverID := cfg.GetSelectedServer()
-	if serverID == "" {
-		return fmt.Errorf("no server selected. Use 'flux-relay server <server-name-or-id>' to select a server")
+	// Get selected project and server (or the --project/--server overrides)
+	projectID, err := currentProjectID(cfg, client, accessToken)
+	if err != nil {
+		return err
+	}
+
+	serverID, err := currentServerID(cfg, client, accessToken, projectID)
+	if err != nil {
+		return err
 	}
 
 	// Join all args to handle queries with spaces
 	query := strings.Join(args, " ")
+	if sqlFile != "" {
+		query, err = readQueryFile(sqlFile)
+		if err != nil {
+			return err
+		}
+	}
+	if sqlClipboard {
+		query, err = clipboardQuery()
+		if err != nil {
+			return err
+		}
+	}
+	typedQuery := query
+
+	if sqlFailOnEmpty || sqlFailOnRows {
+		if err := validateRowCheck(query); err != nil {
+			return err
+		}
+	}
+
+	if sqlDryRun {
+		if err := validateDryRun(query); err != nil {
+			return err
+		}
+	}
+
+	if sqlTransaction {
+		switch {
+		case sqlParamsFile != "" || len(sqlSets) > 0:
+			return fmt.Errorf("--transaction cannot be used with --params or --set")
+		case sqlCountOnly, sqlExplain:
+			return fmt.Errorf("--transaction cannot be used with --count-only or --explain")
+		case sqlWatch > 0:
+			return fmt.Errorf("--transaction cannot be used with --watch")
+		}
+	}
+
+	// Guard against changing other servers' rows by accident. --dry-run runs
+	// nothing, and lists such statements as warnings instead.
+	if !sqlForce && !sqlDryRun {
+		if err := checkServerScope(query); err != nil {
+			return err
+		}
+	}
+
+	// Bind arguments from the --params file and --set values
+	var queryArgs []interface{}
+	if sqlParamsFile != "" || len(sqlSets) > 0 {
+		query, queryArgs, err = loadQueryParams(sqlParamsFile, sqlSets, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	if sqlDryRun {
+		// Failed statements are listed with their errors; don't add usage help
+		cmd.SilenceUsage = true
+		return runSqlDryRun(os.Stdout, client, accessToken, projectID, serverID, query, queryArgs)
+	}
+
+	if sqlCountOnly {
+		counted, err := countQuery(query)
+		if err != nil {
+			return err
+		}
+		query = counted
+	}
+
+	if sqlExplain {
+		explained, err := explainQuery(query)
+		if err != nil {
+			return err
+		}
+		query = explained
+	}
 
 	// Get selected nameserver (optional - for context)
-	nameserverID := cfg.GetSelectedNameserver()
+	nameserverID, err := currentNameserverID(cfg, client, accessToken, projectID, serverID)
+	if err != nil {
+		return err
+	}
 
-	// Create API client and execute query
-	client := api.NewClient(apiURL)
-	
-	// Prepare query args - server_id will be automatically added by the API
-	queryArgs := []interface{}{}
-	
-	// If nameserver is selected, we might want to use it in the query
-	// But the API handles server_id automatically, so we just pass the query as-is
-	queryResponse, err := client.ExecuteQuery(accessToken, projectID, serverID, query, queryArgs)
+	// NULLs are empty unquoted CSV fields unless --null-string says otherwise
+	if outputFormat == "csv" && !cmd.Flags().Changed("null-string") {
+		nullString = ""
+	}
+
+	if sqlWatch > 0 {
+		return watchSql(client, accessToken, projectID, serverID, nameserverID, query, queryArgs)
+	}
+
+	if sqlTransaction {
+		if err := runSqlTransactionCommand(client, accessToken, projectID, serverID, nameserverID, query); err != nil {
+			return explainQueryError(cmd, err)
+		}
+		return nil
+	}
+
+	if cmd.Flags().Changed("max-rows") && sqlMaxRows == 0 && !sqlCountOnly && !sqlExplain {
+		if err := confirmFetchAll(client, accessToken, projectID, serverID, query, queryArgs); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+	}
+
+	// Ctrl+C cancels the request rather than killing the CLI mid-way
+	queryCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	started := time.Now()
+	queryResponse, err := runSqlQuery(client.WithContext(queryCtx), accessToken, projectID, serverID, nameserverID, query, queryArgs)
+	cancelled := err != nil && queryCtx.Err() != nil
+	stopSignals()
+	if !sqlNoHistory {
+		recordQuery("sql", projectID, serverID, nameserverID, typedQuery, started, queryResponse, err)
+	}
+	if cancelled {
+		cmd.SilenceUsage = true
+		fmt.Fprintln(os.Stderr)
+		if keyword := leadingKeyword(query); keyword != "SELECT" && keyword != "WITH" {
+			fmt.Fprintln(os.Stderr, ui.Warn("The request was abandoned; the API may still finish running the statement."))
+		}
+		return &exitCodeError{code: exitInterrupted, err: errors.New("query cancelled")}
+	}
+	if api.IsTimeout(err) {
+		cmd.SilenceUsage = true
+		return queryTimeoutError(query, err, sqlTimeout, false)
+	}
 	if err != nil {
+		return explainQueryError(cmd, err)
+	}
+
+	// Render first so a long table can be shown through the pager
+	var output bytes.Buffer
+	if err := printSqlResult(&output, query, queryResponse, nameserverID); err != nil {
+		// The query ran; only showing its result failed
+		cmd.SilenceUsage = true
+		return err
+	}
+	showPaged(output.String(), outputFormat == "table" && !noPager && outputTemplate == nil)
+
+	if sqlFailOnEmpty || sqlFailOnRows {
+		// The result is already shown; don't add usage help to a failed check
+		cmd.SilenceUsage = true
+		return checkRowCount(queryResponse)
+	}
+	return nil
+}
+
+// queryTimeoutError explains a query that ran out of time after timeout and
+// how to give it longer: twice as long, with --timeout or, in the shell,
+// .timeout
+func queryTimeoutError(query string, err error, timeout time.Duration, inShell bool) error {
+	if timeout <= 0 {
+		timeout = api.DefaultTimeout
+	}
+	longer := shortDuration(2 * timeout)
+	message := fmt.Sprintf("query timed out: %v\nRun it again with a longer timeout, e.g. --timeout %s", err, longer)
+	if inShell {
+		message = fmt.Sprintf("query timed out: %v\nTo give it longer, set .timeout %s and run it again", err, longer)
+	}
+	if keyword := leadingKeyword(query); keyword != "SELECT" && keyword != "WITH" {
+		message += ". The API may still finish running the statement; check before retrying"
+	}
+	return errors.New(message)
+}
+
+// shortDuration formats d without trailing zero units, e.g. "1m" rather
+// than "1m0s"
+func shortDuration(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// queryErrorHint returns advice for the error message of a failed query,
+// heading first, or nil for errors without any. inShell points at the
+// shell's dot commands rather than the flux-relay commands.
+func queryErrorHint(message string, inShell bool) []string {
+	switch {
+	case strings.Contains(message, "SQL_PARSE_ERROR") || strings.Contains(message, "unexpected end of input"):
+		return []string{
+			"Common causes:",
+			"  - Incomplete query (e.g., LIMIT without a number)",
+			"  - Missing semicolon or closing parenthesis",
+			"  - Typo in SQL syntax",
+			"",
+			"Example: SELECT * FROM table WHERE server_id = ? LIMIT 10;",
+		}
+	case strings.Contains(message, "no such table"):
+		if inShell {
+			return []string{
+				"Make sure:",
+				"  - Table name includes nameserver suffix (e.g., conversations_name1)",
+				"  - Use .tables to see available tables",
+				"  - Use .tables --all to also see system and unsuffixed tables",
+				"  - Use .nameservers to see nameserver names",
+			}
+		}
+		return []string{
+			"Make sure:",
+			"  - Table name includes nameserver suffix (e.g., conversations_name1)",
+			"  - Run 'flux-relay ns tables' to see the selected nameserver's tables",
+			"  - Run 'flux-relay ns list' to see nameserver names",
+		}
+	case strings.Contains(message, "server_id"):
+		return []string{"Remember: All queries must include WHERE server_id = ?"}
+	}
+	return nil
+}
+
+// explainQueryError adds queryErrorHint's advice to the error of a failed
+// query when --explain-schema-errors is on
+func explainQueryError(cmd *cobra.Command, err error) error {
+	if !sqlExplainErrors || err == errSessionExpired {
+		return err
+	}
+	cmd.SilenceUsage = true
+	return withQueryErrorHint(err)
+}
+
+// withQueryErrorHint adds queryErrorHint's advice to the error of a failed
+// query, for --explain-schema-errors
+func withQueryErrorHint(err error) error {
+	hint := queryErrorHint(err.Error(), false)
+	if hint == nil {
+		return err
+	}
+	return fmt.Errorf("%w\n\n%s", err, strings.Join(hint, "\n"))
+}
+
+// readQueryFile reads the query for --file, from stdin for "-"
+func readQueryFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read query file: %w", err)
+	}
+	query := strings.TrimSpace(string(data))
+	if query == "" {
+		return "", fmt.Errorf("query file %s is empty", path)
+	}
+	return query, nil
+}
+
+// validateRowCheck rejects --fail-on-empty and --fail-on-rows where they
+// can't be evaluated
+func validateRowCheck(query string) error {
+	switch {
+	case sqlFailOnEmpty && sqlFailOnRows:
+		return fmt.Errorf("--fail-on-empty and --fail-on-rows cannot be used together")
+	case sqlTransaction || sqlWatch > 0 || sqlExplain:
+		return fmt.Errorf("--fail-on-empty and --fail-on-rows cannot be used with --transaction, --watch or --explain")
+	}
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH":
+		return nil
+	default:
+		return fmt.Errorf("--fail-on-empty and --fail-on-rows only work with SELECT queries")
+	}
+}
+
+// checkRowCount applies --fail-on-empty or --fail-on-rows to a result
+func checkRowCount(queryResponse *api.QueryResponse) error {
+	rows := len(queryResponse.Rows)
+	if sqlCountOnly && rows == 1 && len(queryResponse.Rows[0]) == 1 {
+		count, err := strconv.Atoi(render.CellText(queryResponse.Rows[0][0]))
+		if err != nil {
+			return fmt.Errorf("unexpected result for count query")
+		}
+		rows = count
+	}
+
+	switch {
+	case sqlFailOnEmpty && rows == 0:
+		return &exitCodeError{exitAssertionFailed, fmt.Errorf("query returned no rows (--fail-on-empty)")}
+	case sqlFailOnRows && rows > 0:
+		return &exitCodeError{exitAssertionFailed, fmt.Errorf("query returned %d row(s) (--fail-on-rows)", rows)}
+	}
+	return nil
+}
+
+// runSqlQuery executes a query, records it in the audit log and turns API and
+// query failures into errors
+func runSqlQuery(client *api.Client, accessToken, projectID, serverID, nameserverID, query string, queryArgs []interface{}) (*api.QueryResponse, error) {
+	queryResponse, err := withLockRetry(query, func() (*api.QueryResponse, error) {
+		return executeSqlQuery(client, accessToken, projectID, serverID, query, queryArgs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditMutation(projectID, serverID, nameserverID, query, queryResponse)
+
+	return queryResponse, nil
+}
+
+// keepInsertID drops the last insert ID from the result of anything but an
+// INSERT or REPLACE. SQLite reports the connection's most recent insert for
+// every statement, which would be misleading after an UPDATE or SELECT.
+func keepInsertID(query string, queryResponse *api.QueryResponse) {
+	if queryResponse == nil {
+		return
+	}
+	if keyword := leadingKeyword(query); keyword != "INSERT" && keyword != "REPLACE" {
+		queryResponse.LastInsertID = nil
+	}
+}
+
+// serverIDArgs binds the server's ID to every '?' placeholder of a query run
+// without --params or --set, so 'WHERE server_id = ?' limits it to the
+// selected server. Nothing else about the query is changed: a query without
+// such a condition is not filtered. Queries with ':name' or numbered
+// placeholders get no args.
+func serverIDArgs(query, serverID string) []interface{} {
+	placeholders, err := findPlaceholders(query)
+	if err != nil {
+		return []interface{}{}
+	}
+	args := make([]interface{}, 0, len(placeholders))
+	for _, p := range placeholders {
+		if p.name != "" {
+			return []interface{}{}
+		}
+		args = append(args, serverID)
+	}
+	return args
+}
+
+// executeSqlQuery is runSqlQuery without the audit log
+func executeSqlQuery(client *api.Client, accessToken, projectID, serverID, query string, queryArgs []interface{}) (*api.QueryResponse, error) {
+	// Without --params or --set, every '?' is the server's ID
+	if queryArgs == nil {
+		queryArgs = serverIDArgs(query, serverID)
+	}
+
+	var queryResponse *api.QueryResponse
+	var err error
+	if sqlMaxRows > 0 {
+		queryResponse, err = executeCappedQuery(client, accessToken, projectID, serverID, query, queryArgs, sqlMaxRows)
+	} else {
+		queryResponse, err = client.ExecuteQuery(accessToken, projectID, serverID, query, queryArgs)
+	}
+	if err != nil {
+		if api.IsUnauthorized(err) {
+			return nil, errSessionExpired
+		}
 		if apiErr, ok := err.(*api.APIError); ok {
-			return fmt.Errorf("query failed: %s", apiErr.Error())
+			return nil, fmt.Errorf("query failed: %s", apiErr.Error())
 		}
-		return fmt.Errorf("failed to execute query: %w", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 
 	if !queryResponse.Success {
 		if queryResponse.ErrorMessage != "" {
-			return fmt.Errorf("query error: %s", queryResponse.ErrorMessage)
+			return nil, fmt.Errorf("query error: %s", queryResponse.ErrorMessage)
 		}
-		return fmt.Errorf("query failed")
+		return nil, fmt.Errorf("query failed")
 	}
+	keepInsertID(query, queryResponse)
 
-	// Display results
-	if len(queryResponse.Columns) > 0 {
-		// SELECT query - display results in table
-		fmt.Printf("Query executed successfully (%dms)\n\n", queryResponse.ExecutionTime)
-		
-		if len(queryResponse.Rows) == 0 {
-			fmt.Println("No rows returned.")
-			return nil
+	return queryResponse, nil
+}
+
+// executeCappedQuery runs a query keeping at most maxRows rows. The result
+// is streamed, and reading stops at the first row past the cap, so a huge
+// result is never held in memory. A warning says when rows were left out.
+func executeCappedQuery(client *api.Client, accessToken, projectID, serverID, query string, queryArgs []interface{}, maxRows int) (*api.QueryResponse, error) {
+	rows := [][]interface{}{}
+	truncated := false
+	queryResponse, err := client.ExecuteQueryStream(accessToken, projectID, serverID, query, queryArgs, func(columns []string, row []interface{}) error {
+		if len(rows) == maxRows {
+			truncated = true
+			return api.ErrStopStream
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queryResponse.Rows = rows
+	if truncated {
+		fmt.Fprintln(os.Stderr, ui.Warn("Showing the first %d rows (--max-rows); the query returned more. Add a LIMIT or raise --max-rows.", maxRows))
+	}
+	return queryResponse, nil
+}
+
+// fetchAllConfirmRows is the row count above which an explicit --max-rows 0
+// asks before fetching everything
+const fetchAllConfirmRows = 100000
+
+// confirmFetchAll is the guardrail for an explicit --max-rows 0 on a SELECT:
+// it notes that every row is fetched and, when run interactively, counts the
+// rows first and asks before fetching more than fetchAllConfirmRows. A
+// failed count doesn't stop the query; running it reports the error.
+func confirmFetchAll(client *api.Client, accessToken, projectID, serverID, query string, queryArgs []interface{}) error {
+	counted, err := countQuery(query)
+	if err != nil || len(splitSQLStatements(query)) > 1 {
+		return nil
+	}
+	if !sqlQuiet {
+		fmt.Fprintln(os.Stderr, ui.Info("Fetching all rows (--max-rows 0); the result may be large"))
+	}
+	// Only ask when the question can't end up in redirected output
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return nil
+	}
+
+	countResponse, err := executeSqlQuery(client, accessToken, projectID, serverID, counted, queryArgs)
+	if err != nil || len(countResponse.Rows) != 1 || len(countResponse.Rows[0]) != 1 {
+		return checkSession(err)
+	}
+	count, ok := countResponse.Rows[0][0].(float64)
+	if !ok || count <= fetchAllConfirmRows {
+		return nil
+	}
+	if !confirm(fmt.Sprintf("The query returns %.0f rows. Fetch them all?", count)) {
+		return fmt.Errorf("cancelled. Add a LIMIT or use --max-rows N to fetch fewer rows")
+	}
+	return nil
+}
+
+// printWatchResult prints one --watch result as data: a line of JSON, or a
+// YAML document, stamped with the time it was run
+func printWatchResult(queryResponse *api.QueryResponse, now time.Time) error {
+	result := render.BuildJSON(queryResponse, sqlJSONObjects)
+	result.Timestamp = now.Format(time.RFC3339)
+	if outputFormat == render.FormatYAML {
+		fmt.Println("---")
+		return printStructured(os.Stdout, result, "result")
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result as JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printSqlResult renders the result of query to out in the selected output
+// format
+func printSqlResult(out io.Writer, query string, queryResponse *api.QueryResponse, nameserverID string) error {
+	// --count-only prints the bare number in every output format
+	if sqlCountOnly && !sqlExplain {
+		if len(queryResponse.Rows) != 1 || len(queryResponse.Rows[0]) != 1 {
+			return fmt.Errorf("unexpected result for count query")
 		}
+		fmt.Fprintln(out, render.CellText(queryResponse.Rows[0][0]))
+		return nil
+	}
+
+	opts := renderOptions()
+	opts.Kind = resultKind(query)
+
+	// Statements without a result set are reported as usual
+	if outputTemplate != nil && opts.Kind.ReturnsRows(queryResponse) {
+		return renderTemplateRows(out, outputTemplate, queryResponse)
+	}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-		
-		// Print header
-		fmt.Fprintln(w, strings.Join(queryResponse.Columns, "\t"))
-		
-		// Print separator
-		separator := make([]string, len(queryResponse.Columns))
-		for i := range separator {
-			separator[i] = "──"
-		}
-		fmt.Fprintln(w, strings.Join(separator, "\t"))
-		
-		// Print rows
-		for _, row := range queryResponse.Rows {
-			rowStr := make([]string, len(row))
-			for i, val := range row {
-				if val == nil {
-					rowStr[i] = "NULL"
-				} else {
-					// Convert to string, handling JSON encoding for complex types
-					if str, ok := val.(string); ok {
-						rowStr[i] = str
-					} else {
-						jsonBytes, _ := json.Marshal(val)
-						rowStr[i] = string(jsonBytes)
-					}
-				}
+	if err := render.RenderQueryResponse(out, queryResponse, opts); err != nil {
+		return err
+	}
+	if outputFormat != "table" {
+		return nil
+	}
+	if sqlQuiet {
+		if opts.Kind.AffectsRows(queryResponse) {
+			fmt.Fprintf(os.Stderr, "Rows affected: %d\n", queryResponse.RowsAffected)
+			if queryResponse.LastInsertID != nil {
+				fmt.Fprintf(os.Stderr, "Last insert ID: %d\n", *queryResponse.LastInsertID)
 			}
-			fmt.Fprintln(w, strings.Join(rowStr, "\t"))
 		}
-		
-		w.Flush()
-		fmt.Println()
-		fmt.Printf("Rows returned: %d\n", len(queryResponse.Rows))
-	} else {
-		// INSERT/UPDATE/DELETE query
-		fmt.Printf("Query executed successfully (%dms)\n", queryResponse.ExecutionTime)
-		fmt.Printf("Rows affected: %d\n", queryResponse.RowsAffected)
+		return nil
 	}
 
 	if nameserverID != "" {
-		fmt.Println()
-		fmt.Println("Note: Using selected nameserver context")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Note: Using selected nameserver context")
 	}
 
 	return nil
 }
+
+// watchSql re-runs a query every --watch interval until interrupted. On a
+// terminal the screen is redrawn each cycle; otherwise results are appended,
+// and JSON output becomes a stream of one compact object per cycle.
+func watchSql(client *api.Client, accessToken, projectID, serverID, nameserverID, query string, queryArgs []interface{}) error {
+	if sqlWatch < time.Second {
+		return fmt.Errorf("--watch interval must be at least 1s")
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	redraw := outputFormat == "table" && isTerminal(os.Stdout)
+	ticker := time.NewTicker(sqlWatch)
+	defer ticker.Stop()
+
+	for {
+		queryResponse, err := runSqlQuery(client, accessToken, projectID, serverID, nameserverID, query, queryArgs)
+		now := time.Now()
+
+		if structuredOutput() {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			} else if err := printWatchResult(queryResponse, now); err != nil {
+				return err
+			}
+		} else {
+			if redraw {
+				fmt.Print("\033[H\033[2J")
+			}
+			fmt.Printf("Every %s: %s    %s\n\n", sqlWatch, query, now.Format("2006-01-02 15:04:05"))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			} else if err := printSqlResult(os.Stdout, query, queryResponse, nameserverID); err != nil {
+				return err
+			}
+			if !redraw {
+				fmt.Println()
+			}
+		}
+
+		select {
+		case <-sigChan:
+			if isTerminal(os.Stdout) {
+				fmt.Println()
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/render"
+)
+
+func TestQueryErrorHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		inShell bool
+		want    string // a line the hint must contain, or "" for no hint
+	}{
+		{"parse error", "query failed: SQL_PARSE_ERROR: near \"LIMIT\"", false, "Incomplete query"},
+		{"parse error in shell", "query failed: SQL_PARSE_ERROR: near \"LIMIT\"", true, "Incomplete query"},
+		{"end of input", "unexpected end of input", false, "Missing semicolon"},
+		{"no such table", "query failed: SQL_ERROR: no such table: users", false, "flux-relay ns tables"},
+		{"no such table in shell", "query failed: SQL_ERROR: no such table: users", true, ".tables"},
+		{"server_id", "query failed: missing server_id condition", false, "WHERE server_id = ?"},
+		{"server_id in shell", "query failed: missing server_id condition", true, "WHERE server_id = ?"},
+		{"other error", "query failed: UNIQUE constraint failed: users.email", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint := strings.Join(queryErrorHint(tt.message, tt.inShell), "\n")
+			switch {
+			case tt.want == "" && hint != "":
+				t.Errorf("queryErrorHint(%q) = %q, want no hint", tt.message, hint)
+			case !strings.Contains(hint, tt.want):
+				t.Errorf("queryErrorHint(%q) = %q, want it to mention %q", tt.message, hint, tt.want)
+			}
+		})
+	}
+
+	// The shell's hint names dot-commands, the CLI's names subcommands
+	if hint := strings.Join(queryErrorHint("no such table: users", false), "\n"); strings.Contains(hint, ".tables") {
+		t.Errorf("non-shell hint mentions .tables: %q", hint)
+	}
+	if hint := strings.Join(queryErrorHint("no such table: users", true), "\n"); strings.Contains(hint, "flux-relay ns tables") {
+		t.Errorf("shell hint mentions flux-relay ns tables: %q", hint)
+	}
+}
+
+func TestQueryTimeoutErrorSuggestsDoubleTimeout(t *testing.T) {
+	timedOut := errors.New("context deadline exceeded")
+	tests := []struct {
+		timeout time.Duration
+		inShell bool
+		want    string
+	}{
+		{0, false, "--timeout 1m"},
+		{90 * time.Second, false, "--timeout 3m"},
+		{5 * time.Minute, false, "--timeout 10m"},
+		{0, true, "set .timeout 1m and run it again"},
+		{45 * time.Minute, true, "set .timeout 1h30m and run it again"},
+	}
+	for _, tt := range tests {
+		err := queryTimeoutError("SELECT 1", timedOut, tt.timeout, tt.inShell)
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("queryTimeoutError(%s, inShell %t) = %q, want it to suggest %q", tt.timeout, tt.inShell, err, tt.want)
+		}
+	}
+}
+
+func TestResultKind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  render.ResultKind
+	}{
+		{"SELECT * FROM messages_db", render.ResultRows},
+		{"(SELECT 1)", render.ResultRows},
+		{"WITH recent AS (SELECT * FROM messages_db LIMIT 10) SELECT id FROM recent", render.ResultRows},
+		{"WITH ids AS (SELECT id FROM a_db) INSERT INTO b_db SELECT id FROM ids RETURNING id", render.ResultChangeReturning},
+		{"WITH ids AS (SELECT id FROM a_db) DELETE FROM b_db WHERE id IN (SELECT id FROM ids)", render.ResultChange},
+		{"INSERT INTO a_db (id) VALUES (1) RETURNING id, created_at", render.ResultChangeReturning},
+		{"UPDATE a_db SET n = n + 1 WHERE server_id = ? RETURNING (n * 2) AS doubled", render.ResultChangeReturning},
+		{"INSERT INTO a_db (id, returning) VALUES (1, 2)", render.ResultChange},
+		{"INSERT INTO a_db (note) VALUES ('RETURNING id')", render.ResultChange},
+		{"DELETE FROM a_db WHERE server_id = ? -- RETURNING id", render.ResultChange},
+		{"/* WITH x AS (SELECT 1) */ DELETE FROM a_db WHERE server_id = ?", render.ResultChange},
+		{"PRAGMA table_info(a_db)", render.ResultRows},
+		{"EXPLAIN QUERY PLAN SELECT * FROM a_db", render.ResultRows},
+		{"CREATE TABLE a_db (id INTEGER PRIMARY KEY)", render.ResultChange},
+		{"DROP TABLE a_db", render.ResultChange},
+		{"VACUUM", render.ResultUnknown},
+	}
+	for _, tt := range tests {
+		if got := resultKind(tt.query); got != tt.want {
+			t.Errorf("resultKind(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"strings"
+)
+
+// sqlToken is a single lexical token of a SQL statement
+type sqlToken struct {
+	text  string
+	start int
+	end   int
+	word  bool // bare identifier or keyword (not quoted, not punctuation)
+}
+
+// tableKeywords are the keywords that are followed by a table name
+var tableKeywords = map[string]bool{
+	"FROM":       true,
+	"JOIN":       true,
+	"INTO":       true,
+	"UPDATE":     true,
+	"TABLE":      true,
+	"REFERENCES": true, // a foreign key's parent table
+}
+
+// clauseKeywords are words that can follow a table name but are never an alias
+var clauseKeywords = map[string]bool{
+	"WHERE": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true,
+	"OUTER": true, "CROSS": true, "NATURAL": true, "FULL": true, "ON": true,
+	"USING": true, "GROUP": true, "ORDER": true, "LIMIT": true, "SET": true,
+	"VALUES": true, "SELECT": true, "UNION": true, "EXCEPT": true,
+	"INTERSECT": true, "HAVING": true, "WINDOW": true, "DEFAULT": true,
+	"RETURNING": true, "INDEXED": true, "NOT": true, "AS": true,
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// scanSQLTokens splits a query into tokens, skipping whitespace and comments.
+// String literals and quoted identifiers are kept as single non-word tokens.
+func scanSQLTokens(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for j < len(query) {
+				if query[j] == closing {
+					// Doubled quote is an escaped quote
+					if closing != ']' && j+1 < len(query) && query[j+1] == closing {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j < len(query) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: query[i:j], start: i, end: j})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: query[i:j], start: i, end: j, word: true})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{text: query[i : i+1], start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens
+}
+
+// findTableRefs returns the indexes of tokens that name a table, i.e. bare
+// identifiers following FROM, JOIN, INTO, UPDATE, TABLE or REFERENCES, and
+// the first ON of a CREATE INDEX/TRIGGER statement. Schema-qualified names,
+// table-valued functions and CTE names are not reported.
+func findTableRefs(tokens []sqlToken) []int {
+	// In CREATE TABLE, ON only starts a conflict or foreign key clause
+	createsIndexOrTrigger := false
+	if len(tokens) > 0 && strings.EqualFold(tokens[0].text, "CREATE") {
+		for _, tok := range tokens[1:] {
+			upper := strings.ToUpper(tok.text)
+			if upper != "TEMP" && upper != "TEMPORARY" && upper != "UNIQUE" {
+				createsIndexOrTrigger = upper == "INDEX" || upper == "TRIGGER"
+				break
+			}
+		}
+	}
+	seenOn := false
+
+	// Names defined by a WITH clause look like "name AS ("
+	cteNames := make(map[string]bool)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].word && strings.EqualFold(tokens[i+1].text, "AS") && tokens[i+2].text == "(" {
+			cteNames[strings.ToLower(tokens[i].text)] = true
+		}
+	}
+
+	var refs []int
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].word {
+			continue
+		}
+		keyword := strings.ToUpper(tokens[i].text)
+		if keyword == "ON" && createsIndexOrTrigger && !seenOn {
+			seenOn = true
+		} else if !tableKeywords[keyword] {
+			continue
+		}
+
+		j := i + 1
+		for {
+			// Skip modifiers such as IF NOT EXISTS and UPDATE OR REPLACE
+			for j < len(tokens) && tokens[j].word {
+				upper := strings.ToUpper(tokens[j].text)
+				if upper == "IF" || upper == "NOT" || upper == "EXISTS" {
+					j++
+					continue
+				}
+				if keyword == "UPDATE" && upper == "OR" && j+1 < len(tokens) {
+					j += 2
+					continue
+				}
+				break
+			}
+			if j >= len(tokens) || !tokens[j].word {
+				break
+			}
+
+			name := tokens[j]
+			next := ""
+			if j+1 < len(tokens) {
+				next = tokens[j+1].text
+			}
+			isFunction := next == "(" && (keyword == "FROM" || keyword == "JOIN")
+			if next != "." && !isFunction && !cteNames[strings.ToLower(name.text)] {
+				refs = append(refs, j)
+			}
+
+			// Only FROM accepts a comma-separated list of tables
+			if keyword != "FROM" {
+				break
+			}
+			k := j + 1
+			if k < len(tokens) && strings.EqualFold(tokens[k].text, "AS") {
+				k++
+			}
+			if k < len(tokens) && tokens[k].word && !clauseKeywords[strings.ToUpper(tokens[k].text)] {
+				k++
+			}
+			if k < len(tokens) && tokens[k].text == "," {
+				j = k + 1
+				continue
+			}
+			break
+		}
+	}
+	return refs
+}
+
+// addNameserverSuffix rewrites unsuffixed table references in query to use
+// the nameserver's suffix, e.g. "conversations" becomes "conversations_name1".
+// Tables that already carry the suffix and SQLite internal tables are left alone.
+func addNameserverSuffix(query, nameserverName string) string {
+	if nameserverName == "" {
+		return query
+	}
+	suffix := "_" + nameserverName
+
+	tokens := scanSQLTokens(query)
+	var result strings.Builder
+	last := 0
+	for _, idx := range findTableRefs(tokens) {
+		name := strings.ToLower(tokens[idx].text)
+		if strings.HasSuffix(name, strings.ToLower(suffix)) || strings.HasPrefix(name, "sqlite_") {
+			continue
+		}
+		result.WriteString(query[last:tokens[idx].end])
+		result.WriteString(suffix)
+		last = tokens[idx].end
+	}
+	result.WriteString(query[last:])
+	return result.String()
+}
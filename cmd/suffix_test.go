@@ -0,0 +1,30 @@
+package cmd
+
+import "testing"
+
+func TestAddNameserverSuffix(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM messages WHERE server_id = ?", "SELECT * FROM messages_db WHERE server_id = ?"},
+		{"SELECT * FROM messages_db", "SELECT * FROM messages_db"},
+		{
+			"CREATE TABLE messages (id TEXT, conversation_id TEXT REFERENCES conversations(id))",
+			"CREATE TABLE messages_db (id TEXT, conversation_id TEXT REFERENCES conversations_db(id))",
+		},
+		{
+			"CREATE TABLE messages (id TEXT, FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE)",
+			"CREATE TABLE messages_db (id TEXT, FOREIGN KEY (conversation_id) REFERENCES conversations_db (id) ON DELETE CASCADE)",
+		},
+		{
+			"CREATE TRIGGER log AFTER INSERT ON messages BEGIN INSERT INTO audit VALUES (new.id); END",
+			"CREATE TRIGGER log AFTER INSERT ON messages_db BEGIN INSERT INTO audit_db VALUES (new.id); END",
+		},
+	}
+	for _, tt := range tests {
+		if got := addNameserverSuffix(tt.query, "db"); got != tt.want {
+			t.Errorf("addNameserverSuffix(%q) =\n  %q\nwant\n  %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+)
+
+// sqlOutputTemplate is the --output-template flag, and outputTemplate the
+// template parsed from it
+var (
+	sqlOutputTemplate string
+	outputTemplate    *template.Template
+)
+
+// rowNumberField is the template field holding a row's 1-based number
+const rowNumberField = "__rownum"
+
+// parseOutputTemplate parses --output-template. A value starting with '@'
+// names a file holding the template.
+func parseOutputTemplate(value string) (*template.Template, error) {
+	text := value
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --output-template file: %w", err)
+		}
+		text = string(data)
+	}
+	// A misspelled column is an error rather than "<no value>"
+	tmpl, err := template.New("row").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --output-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplateRows writes every row of a result through tmpl, each on its
+// own line. A row is a map of column name to its text, nil for NULL, plus
+// __rownum.
+func renderTemplateRows(out io.Writer, tmpl *template.Template, queryResponse *api.QueryResponse) error {
+	var buf bytes.Buffer
+	for i, row := range queryResponse.Rows {
+		data := make(map[string]interface{}, len(queryResponse.Columns)+1)
+		for j, col := range queryResponse.Columns {
+			var value interface{}
+			if j < len(row) && row[j] != nil {
+				value = render.CellText(row[j])
+			}
+			data[col] = value
+		}
+		data[rowNumberField] = i + 1
+
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("--output-template failed on row %d: %w", i+1, err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := out.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
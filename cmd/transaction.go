@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/postacksol/flux-relay-cli/internal/ui"
+)
+
+var sqlSummary bool
+
+// summaryStatementWidth is how much of each statement --summary shows
+const summaryStatementWidth = 50
+
+// transactionControl are statements --transaction issues itself
+var transactionControl = map[string]bool{
+	"BEGIN":     true,
+	"COMMIT":    true,
+	"END":       true,
+	"ROLLBACK":  true,
+	"SAVEPOINT": true,
+	"RELEASE":   true,
+}
+
+// transactionStatements splits a --transaction script into its statements
+func transactionStatements(script string) ([]string, error) {
+	statements := splitSQLStatements(script)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no statements to run")
+	}
+	for i, stmt := range statements {
+		if keyword := leadingKeyword(stmt); transactionControl[keyword] {
+			return nil, fmt.Errorf("statement %d: don't use %s with --transaction; BEGIN, COMMIT and ROLLBACK are sent for you", i+1, keyword)
+		}
+	}
+	return statements, nil
+}
+
+// runSqlTransaction runs statements between BEGIN and COMMIT, rolling back if
+// any of them fails. Every request to the query endpoint may run on its own
+// database connection, in which case a transaction can't span requests; this
+// is detected before any statement runs by issuing BEGIN twice, since the
+// second one only fails if the first is still open.
+func runSqlTransaction(client *api.Client, accessToken, projectID, serverID, nameserverID string, statements []string) ([]*api.QueryResponse, error) {
+	if _, err := executeSqlQuery(client, accessToken, projectID, serverID, "BEGIN", nil); err != nil {
+		if err == errSessionExpired {
+			return nil, err
+		}
+		return nil, fmt.Errorf("transactions are not supported by the query endpoint (BEGIN failed: %v). Nothing was run", err)
+	}
+
+	_, err := executeSqlQuery(client, accessToken, projectID, serverID, "BEGIN", nil)
+	if err == nil {
+		// The first BEGIN did not survive the request
+		executeSqlQuery(client, accessToken, projectID, serverID, "ROLLBACK", nil)
+		return nil, fmt.Errorf("transactions are not supported by the query endpoint: each request runs on its own connection. Nothing was run")
+	}
+	if !strings.Contains(err.Error(), "within a transaction") {
+		return nil, rollbackTransaction(client, accessToken, projectID, serverID, fmt.Errorf("could not confirm the transaction is open: %w", err))
+	}
+
+	results := make([]*api.QueryResponse, 0, len(statements))
+	for i, stmt := range statements {
+		queryResponse, err := executeSqlQuery(client, accessToken, projectID, serverID, stmt, nil)
+		if err != nil {
+			return nil, rollbackTransaction(client, accessToken, projectID, serverID, fmt.Errorf("statement %d failed: %w", i+1, err))
+		}
+		results = append(results, queryResponse)
+	}
+
+	if _, err := executeSqlQuery(client, accessToken, projectID, serverID, "COMMIT", nil); err != nil {
+		return nil, rollbackTransaction(client, accessToken, projectID, serverID, fmt.Errorf("COMMIT failed: %w", err))
+	}
+
+	// Only committed statements go to the audit log
+	for i, stmt := range statements {
+		auditMutation(projectID, serverID, nameserverID, stmt, results[i])
+	}
+
+	return results, nil
+}
+
+// rollbackTransaction issues ROLLBACK after cause and reports whether the
+// rollback itself succeeded
+func rollbackTransaction(client *api.Client, accessToken, projectID, serverID string, cause error) error {
+	if _, err := executeSqlQuery(client, accessToken, projectID, serverID, "ROLLBACK", nil); err != nil {
+		return fmt.Errorf("%w; ROLLBACK also failed, the transaction may still be open: %v", cause, err)
+	}
+	return fmt.Errorf("%w; transaction rolled back, no changes were made", cause)
+}
+
+// printTransactionResult renders the results of a committed transaction
+func printTransactionResult(out io.Writer, statements []string, results []*api.QueryResponse, nameserverID string) error {
+	if structuredOutput() {
+		combined := make([]render.QueryJSON, len(results))
+		for i, queryResponse := range results {
+			combined[i] = render.BuildJSON(queryResponse, sqlJSONObjects)
+		}
+		return printStructured(out, combined, "result")
+	}
+
+	for i, queryResponse := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if outputFormat == "table" && !sqlQuiet {
+			fmt.Fprintf(out, "── Statement %d/%d: %s\n", i+1, len(results), firstLine(statements[i]))
+		}
+		if err := printSqlResult(out, statements[i], queryResponse, ""); err != nil {
+			return err
+		}
+	}
+
+	if sqlSummary {
+		fmt.Fprintln(out)
+		printStatementSummary(out, statements, results)
+	}
+
+	if outputFormat == "table" && !sqlQuiet {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, ui.Success("Transaction committed (%d statements)", len(results)))
+		if nameserverID != "" {
+			fmt.Fprintln(out, "Note: Using selected nameserver context")
+		}
+	}
+	return nil
+}
+
+// printStatementSummary prints a table of each statement's rows and server
+// execution time, for --summary, and names the slowest statement
+func printStatementSummary(out io.Writer, statements []string, results []*api.QueryResponse) {
+	fmt.Fprintln(out, "── Summary")
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "#\tSTATEMENT\tROWS\tTIME")
+	fmt.Fprintln(w, "─\t─────────\t────\t────")
+	slowest := 0
+	for i, queryResponse := range results {
+		rows := fmt.Sprintf("%d affected", queryResponse.RowsAffected)
+		if resultKind(statements[i]).ReturnsRows(queryResponse) {
+			rows = fmt.Sprintf("%d returned", len(queryResponse.Rows))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%dms\n", i+1, summaryStatement(statements[i]), rows, queryResponse.ExecutionTime)
+		if queryResponse.ExecutionTime > results[slowest].ExecutionTime {
+			slowest = i
+		}
+	}
+	w.Flush()
+	if len(results) > 1 {
+		fmt.Fprintf(out, "Slowest: statement %d (%dms)\n", slowest+1, results[slowest].ExecutionTime)
+	}
+}
+
+// summaryStatement puts a statement on one line, cut to summaryStatementWidth
+func summaryStatement(stmt string) string {
+	runes := []rune(strings.Join(strings.Fields(stmt), " "))
+	if len(runes) <= summaryStatementWidth {
+		return string(runes)
+	}
+	return string(runes[:summaryStatementWidth-1]) + "…"
+}
+
+// firstLine returns the first line of a statement, marking it if more follows
+func firstLine(stmt string) string {
+	if i := strings.IndexByte(stmt, '\n'); i >= 0 {
+		return strings.TrimSpace(stmt[:i]) + " ..."
+	}
+	return stmt
+}
+
+// runSqlTransactionCommand is the --transaction form of the sql command
+func runSqlTransactionCommand(client *api.Client, accessToken, projectID, serverID, nameserverID, script string) error {
+	statements, err := transactionStatements(script)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	results, err := runSqlTransaction(client, accessToken, projectID, serverID, nameserverID, statements)
+	if !sqlNoHistory {
+		// The whole script is one history entry, counting the rows of every statement
+		combined := &api.QueryResponse{Success: true}
+		for i, queryResponse := range results {
+			if resultKind(statements[i]).ReturnsRows(queryResponse) {
+				combined.RowsAffected += len(queryResponse.Rows)
+			} else {
+				combined.RowsAffected += queryResponse.RowsAffected
+			}
+		}
+		recordQuery("sql", projectID, serverID, nameserverID, script, started, combined, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	if err := printTransactionResult(&output, statements, results, nameserverID); err != nil {
+		return err
+	}
+	showPaged(output.String(), outputFormat == "table" && !noPager)
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/postacksol/flux-relay-cli/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the CLI version",
+	Long: `Show the version of the CLI and the commit and date it was built from,
+like --version.
+
+With --output json or yaml the build information is printed as an object,
+so scripts can check the installed version without parsing the text.
+--version -o json does the same.
+
+Examples:
+  flux-relay version
+  flux-relay version -o json
+  flux-relay --version -o json`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	setOutputFormats(versionCmd, render.FormatJSON, render.FormatYAML)
+	rootCmd.AddCommand(versionCmd)
+}
+
+// buildInfo is the build information printed by version -o json
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+func currentBuildInfo() buildInfo {
+	return buildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// versionText returns what version and --version print: a line like
+// "flux-relay 1.2.3 (abcdef1, 2024-05-01)", or the build information as
+// JSON or YAML when --output asks for it
+func versionText() string {
+	if !structuredOutput() {
+		return fmt.Sprintf("flux-relay %s (%s, %s)\n", version, commit, date)
+	}
+	var out bytes.Buffer
+	if err := printStructured(&out, currentBuildInfo(), "version"); err != nil {
+		return err.Error() + "\n"
+	}
+	return out.String()
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(); err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, versionText())
+	return nil
+}
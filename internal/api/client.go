@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
-	"regexp"
+	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,15 +21,168 @@ import (
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+	setupErr   error // set when BaseURL is malformed or the transport can't be built; returned by every request
+	ctx        context.Context
 }
 
+// DefaultTimeout bounds each request of a client created with NewClient
+const DefaultTimeout = 30 * time.Second
+
+// NewClient creates a client for the API at baseURL. The URL is normalized
+// with NormalizeBaseURL; if it is malformed every request fails with the
+// reason before anything is sent. It connects as Transport configures.
 func NewClient(baseURL string) *Client {
+	return NewClientWithTimeout(baseURL, DefaultTimeout)
+}
+
+// NewClientWithTimeout creates a client like NewClient whose requests give up
+// after timeout instead of DefaultTimeout, e.g. for one slow report. The
+// timeout covers reading the response too. When it expires the request is
+// cancelled like a done context cancels it (see WithContext): the connection
+// is closed, which is the only way the API learns to stop.
+func NewClientWithTimeout(baseURL string, timeout time.Duration) *Client {
+	normalized, err := NormalizeBaseURL(baseURL)
+	if err != nil {
+		normalized = baseURL
+	}
+	transport, transportErr := Transport.roundTripper()
+	if err == nil {
+		err = transportErr
+	}
 	return &Client{
-		BaseURL: baseURL,
+		BaseURL: normalized,
 		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   timeout,
+			Transport: transport,
 		},
+		setupErr: err,
+	}
+}
+
+// NormalizeBaseURL checks that an API base URL is an absolute http or https
+// URL and strips trailing slashes, so paths can be appended to it
+func NormalizeBaseURL(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", fmt.Errorf("API URL is empty")
+	}
+	if !strings.Contains(trimmed, "://") {
+		return "", fmt.Errorf("invalid API URL %q: missing scheme (did you mean https://%s?)", raw, trimmed)
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return "", fmt.Errorf("invalid API URL %q: %v", raw, err)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return "", fmt.Errorf("invalid API URL %q: scheme must be http or https", raw)
+	case u.Host == "":
+		return "", fmt.Errorf("invalid API URL %q: missing host", raw)
+	case u.User != nil:
+		return "", fmt.Errorf("invalid API URL %q: credentials don't belong in the URL, use 'flux-relay login'", raw)
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", fmt.Errorf("invalid API URL %q: remove the query string or fragment", raw)
+	}
+
+	return strings.TrimRight(u.Scheme+"://"+u.Host+u.EscapedPath(), "/"), nil
+}
+
+// Version is the CLI version sent in the User-Agent header. The cmd package
+// sets it from the version stamped in at build time.
+var Version = "dev"
+
+// UserAgent returns the User-Agent sent with every request, e.g.
+// "flux-relay-cli/1.2.3 (linux/amd64)"
+func UserAgent() string {
+	return fmt.Sprintf("flux-relay-cli/%s (%s/%s)", Version, runtime.GOOS, runtime.GOARCH)
+}
+
+// WithContext returns a copy of the client whose requests are cancelled
+// when ctx is done
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// newRequest creates a request with the headers every API call carries
+func (c *Client) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	if c.setupErr != nil {
+		return nil, c.setupErr
 	}
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent())
+	req.Header.Set(requestIDHeader, newRequestID())
+	return req, nil
+}
+
+// requestIDHeader carries an ID for each request so a failure reported by a
+// user can be matched to the server's logs
+const requestIDHeader = "X-Request-ID"
+
+// Verbose logs every request with its status, duration and request ID to stderr
+var Verbose bool
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestID returns the ID the server echoed for a response, or else the ID
+// the request was sent with
+func requestID(resp *http.Response) string {
+	if id := resp.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get(requestIDHeader)
+	}
+	return ""
+}
+
+// do sends a request and reads the whole response body
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// send sends req and returns as soon as the response headers arrive. The
+// caller must close the response body.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "[api] %s %s failed after %dms (request id: %s): %v\n",
+				req.Method, req.URL.Path, time.Since(start).Milliseconds(), req.Header.Get(requestIDHeader), err)
+		}
+		return nil, fmt.Errorf("%w (request id: %s)", err, req.Header.Get(requestIDHeader))
+	}
+	if Verbose {
+		fmt.Fprintf(os.Stderr, "[api] %s %s -> %d in %dms (request id: %s)\n",
+			req.Method, req.URL.Path, resp.StatusCode, time.Since(start).Milliseconds(), requestID(resp))
+	}
+	return resp, nil
 }
 
 type DeviceCodeResponse struct {
@@ -47,46 +207,145 @@ type TokenResponse struct {
 type APIError struct {
 	ErrorCode        string `json:"error"`
 	ErrorDescription string `json:"error_description"`
+	StatusCode       int    `json:"-"` // HTTP status of the response, when known
+	RequestID        string `json:"-"` // ID of the failed request, for support
 }
 
 func (e *APIError) Error() string {
+	msg := e.ErrorCode
 	if e.ErrorDescription != "" {
-		return fmt.Sprintf("%s: %s", e.ErrorCode, e.ErrorDescription)
+		msg = fmt.Sprintf("%s: %s", e.ErrorCode, e.ErrorDescription)
 	}
-	return e.ErrorCode
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id: %s)", e.RequestID)
+	}
+	return msg
 }
 
 func (e *APIError) Code() string {
 	return e.ErrorCode
 }
 
-func (c *Client) InitiateDeviceCode() (*DeviceCodeResponse, error) {
-	req, err := http.NewRequest("POST", c.BaseURL+"/api/cli/auth/initiate", nil)
-	if err != nil {
-		return nil, err
+// IsUnauthorized reports whether err is an API rejection of the access token,
+// i.e. an HTTP 401 or an "Unauthorized" error code
+func IsUnauthorized(err error) bool {
+	var nonJSON *NonJSONResponseError
+	if errors.As(err, &nonJSON) {
+		return nonJSON.StatusCode == http.StatusUnauthorized
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
 	}
+	return apiErr.StatusCode == http.StatusUnauthorized || strings.EqualFold(apiErr.ErrorCode, "unauthorized")
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// IsTimeout reports whether err is a request that ran out of time, i.e. hit
+// the client's timeout or a context deadline
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// NonJSONResponseError is returned when the API answers with something other
+// than JSON, typically an HTML error page from a proxy, gateway or captive
+// portal. The body is only logged, with Verbose.
+type NonJSONResponseError struct {
+	StatusCode  int
+	ContentType string
+	RequestID   string
+}
+
+func (e *NonJSONResponseError) Error() string {
+	msg := fmt.Sprintf("unexpected non-JSON response (HTTP %d", e.StatusCode)
+	if e.ContentType != "" {
+		msg += ", " + e.ContentType
+	}
+	msg += "); the API may be unavailable"
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// maxLoggedBody is how much of a non-JSON body is logged with Verbose
+const maxLoggedBody = 4096
+
+// nonJSONError builds the error for a non-JSON response and logs its body
+// with Verbose
+func nonJSONError(resp *http.Response, body []byte) error {
+	contentType := resp.Header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	if Verbose {
+		logged := body
+		if len(logged) > maxLoggedBody {
+			logged = logged[:maxLoggedBody]
+		}
+		fmt.Fprintf(os.Stderr, "[api] non-JSON response body (%d bytes):\n%s\n", len(body), logged)
+	}
+	return &NonJSONResponseError{
+		StatusCode:  resp.StatusCode,
+		ContentType: contentType,
+		RequestID:   requestID(resp),
+	}
+}
+
+// errorResponse converts a failed response to an error: an *APIError for a
+// JSON body, using fallbackCode if it has no error code of its own, or a
+// *NonJSONResponseError for anything else
+func errorResponse(resp *http.Response, body []byte, fallbackCode string) error {
+	if !json.Valid(body) {
+		return nonJSONError(resp, body)
+	}
+	var apiErr APIError
+	if err := json.Unmarshal(body, &apiErr); err == nil {
+		apiErr.StatusCode = resp.StatusCode
+		apiErr.RequestID = requestID(resp)
+		return &apiErr
+	}
+	return &APIError{
+		ErrorCode:        fallbackCode,
+		ErrorDescription: string(body),
+		StatusCode:       resp.StatusCode,
+		RequestID:        requestID(resp),
+	}
+}
+
+// decodeJSON decodes a successful response body into v. A body that isn't
+// JSON at all, such as a captive portal's login page, gives a
+// *NonJSONResponseError.
+func decodeJSON(resp *http.Response, body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		if !json.Valid(body) {
+			return nonJSONError(resp, body)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) InitiateDeviceCode() (*DeviceCodeResponse, error) {
+	req, err := c.newRequest("POST", c.BaseURL+"/api/cli/auth/initiate", nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to initiate device code: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to initiate device code")
 	}
 
 	var deviceCode DeviceCodeResponse
-	if err := json.Unmarshal(body, &deviceCode); err != nil {
+	if err := decodeJSON(resp, body, &deviceCode); err != nil {
 		return nil, err
 	}
 
@@ -101,18 +360,12 @@ func (c *Client) GetToken(deviceCode string) (*TokenResponse, error) {
 	// URL encode the device code to prevent injection
 	encodedCode := url.QueryEscape(deviceCode)
 	url := fmt.Sprintf("%s/api/cli/auth/token?device_code=%s", c.BaseURL, encodedCode)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -121,28 +374,24 @@ func (c *Client) GetToken(deviceCode string) (*TokenResponse, error) {
 		// Still pending
 		var apiErr APIError
 		if err := json.Unmarshal(body, &apiErr); err == nil {
+			apiErr.StatusCode = resp.StatusCode
+			apiErr.RequestID = requestID(resp)
 			return nil, &apiErr
 		}
 		return nil, &APIError{
 			ErrorCode:        "authorization_pending",
 			ErrorDescription: "The user has not yet completed the authorization flow.",
+			StatusCode:       resp.StatusCode,
+			RequestID:        requestID(resp),
 		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		// If we can't parse the error, create a generic one
-		return nil, &APIError{
-			ErrorCode:        "api_error",
-			ErrorDescription: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)),
-		}
+		return nil, errorResponse(resp, body, "api_error")
 	}
 
 	var token TokenResponse
-	if err := json.Unmarshal(body, &token); err != nil {
+	if err := decodeJSON(resp, body, &token); err != nil {
 		return nil, err
 	}
 
@@ -170,34 +419,24 @@ func (u *UserInfo) Username() string {
 }
 
 func (c *Client) GetCurrentUser(accessToken string) (*UserInfo, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+"/api/developer/me", nil)
+	req, err := c.newRequest("GET", c.BaseURL+"/api/developer/me", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to get user info: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to get user info")
 	}
 
 	var userInfo UserInfo
-	if err := json.Unmarshal(body, &userInfo); err != nil {
+	if err := decodeJSON(resp, body, &userInfo); err != nil {
 		return nil, err
 	}
 
@@ -218,38 +457,100 @@ type ProjectsResponse struct {
 }
 
 func (c *Client) ListProjects(accessToken string) (*ProjectsResponse, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+"/api/developer/projects", nil)
+	req, err := c.newRequest("GET", c.BaseURL+"/api/developer/projects", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorResponse(resp, body, "failed to list projects")
+	}
+
+	var projectsResponse ProjectsResponse
+	if err := decodeJSON(resp, body, &projectsResponse); err != nil {
+		return nil, err
+	}
+
+	return &projectsResponse, nil
+}
+
+// RenameProject changes a project's name and returns the updated project
+func (c *Client) RenameProject(accessToken string, projectID string, name string) (*Project, error) {
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
+	}
+	// URL encode to prevent path injection
+	encodedProjectID := url.PathEscape(projectID)
+
+	jsonData, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest("PATCH", c.BaseURL+"/api/developer/projects/"+encodedProjectID, strings.NewReader(string(jsonData)))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to list projects: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to rename project")
 	}
 
-	var projectsResponse ProjectsResponse
-	if err := json.Unmarshal(body, &projectsResponse); err != nil {
+	// The project may come wrapped as {"project": {...}} or on its own
+	var response struct {
+		Project *Project `json:"project"`
+	}
+	if err := decodeJSON(resp, body, &response); err != nil {
+		return nil, err
+	}
+	if response.Project != nil {
+		return response.Project, nil
+	}
+	var project Project
+	if err := decodeJSON(resp, body, &project); err != nil {
 		return nil, err
 	}
+	return &project, nil
+}
 
-	return &projectsResponse, nil
+// DeleteProject deletes a project together with its servers and nameservers
+func (c *Client) DeleteProject(accessToken string, projectID string) error {
+	if err := validateID("project", projectID); err != nil {
+		return err
+	}
+	// URL encode to prevent path injection
+	encodedProjectID := url.PathEscape(projectID)
+	req, err := c.newRequest("DELETE", c.BaseURL+"/api/developer/projects/"+encodedProjectID, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return errorResponse(resp, body, "failed to delete project")
+	}
+	return nil
 }
 
 type Server struct {
@@ -267,53 +568,114 @@ type ServersResponse struct {
 	Servers []Server `json:"servers"`
 }
 
-// validateID validates that an ID contains only safe characters
-func validateID(id string) error {
-	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, id)
-	if !matched || len(id) == 0 || len(id) > 100 {
-		return fmt.Errorf("invalid ID format")
+// maxIDLength is the longest project, server or nameserver ID accepted
+const maxIDLength = 100
+
+// validateID checks that an ID is 1-100 characters from [a-zA-Z0-9_-], so it
+// can't change the path it is put into. field names the kind of ID, e.g.
+// "project", for the error.
+func validateID(field, id string) error {
+	switch {
+	case id == "":
+		return fmt.Errorf("invalid %s ID: it is empty", field)
+	case len(id) > maxIDLength:
+		return fmt.Errorf("invalid %s ID: it is %d characters long, the maximum is %d", field, len(id), maxIDLength)
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return fmt.Errorf("invalid %s ID %q: it contains %q; only letters, digits, '_' and '-' are allowed", field, id, r)
+		}
 	}
 	return nil
 }
 
 func (c *Client) ListServers(accessToken string, projectID string) (*ServersResponse, error) {
-	if err := validateID(projectID); err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
 	}
 	// URL encode to prevent path injection
 	encodedProjectID := url.PathEscape(projectID)
-	req, err := http.NewRequest("GET", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers", nil)
+	req, err := c.newRequest("GET", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorResponse(resp, body, "failed to list servers")
+	}
+
+	var serversResponse ServersResponse
+	if err := decodeJSON(resp, body, &serversResponse); err != nil {
+		return nil, err
+	}
+
+	return &serversResponse, nil
+}
+
+// UpdateServerRequest holds the server fields to change. Nil fields are left
+// as they are.
+type UpdateServerRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// RenameServer updates a server's name and/or description and returns the
+// updated server
+func (c *Client) RenameServer(accessToken string, projectID string, serverID string, update UpdateServerRequest) (*Server, error) {
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
+	}
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
+	}
+	// URL encode to prevent path injection
+	encodedProjectID := url.PathEscape(projectID)
+	encodedServerID := url.PathEscape(serverID)
+
+	jsonData, err := json.Marshal(update)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to list servers: %s", string(body))
+	req, err := c.newRequest("PATCH", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers/"+encodedServerID, strings.NewReader(string(jsonData)))
+	if err != nil {
+		return nil, err
 	}
 
-	var serversResponse ServersResponse
-	if err := json.Unmarshal(body, &serversResponse); err != nil {
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, body, err := c.do(req)
+	if err != nil {
 		return nil, err
 	}
 
-	return &serversResponse, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorResponse(resp, body, "failed to update server")
+	}
+
+	// The server may come wrapped as {"server": {...}} or on its own
+	var response struct {
+		Server *Server `json:"server"`
+	}
+	if err := decodeJSON(resp, body, &response); err != nil {
+		return nil, err
+	}
+	if response.Server != nil {
+		return response.Server, nil
+	}
+	var server Server
+	if err := decodeJSON(resp, body, &server); err != nil {
+		return nil, err
+	}
+	return &server, nil
 }
 
 type Database struct {
@@ -331,43 +693,33 @@ type DatabasesResponse struct {
 }
 
 func (c *Client) ListDatabases(accessToken string, projectID string, serverID string) (*DatabasesResponse, error) {
-	if err := validateID(projectID); err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
 	}
-	if err := validateID(serverID); err != nil {
-		return nil, fmt.Errorf("invalid server ID: %w", err)
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
 	}
 	// URL encode to prevent path injection
 	encodedProjectID := url.PathEscape(projectID)
 	encodedServerID := url.PathEscape(serverID)
-	req, err := http.NewRequest("GET", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers/"+encodedServerID+"/databases", nil)
+	req, err := c.newRequest("GET", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers/"+encodedServerID+"/databases", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to list databases: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to list databases")
 	}
 
 	var databasesResponse DatabasesResponse
-	if err := json.Unmarshal(body, &databasesResponse); err != nil {
+	if err := decodeJSON(resp, body, &databasesResponse); err != nil {
 		return nil, err
 	}
 
@@ -381,19 +733,74 @@ type QueryRequest struct {
 
 type QueryResponse struct {
 	Columns      []string        `json:"columns"`
+	ColumnTypes  []string        `json:"columnTypes,omitempty"` // declared types, when the API reports them
 	Rows         [][]interface{} `json:"rows"`
 	RowsAffected int             `json:"rowsAffected"`
 	ExecutionTime int            `json:"executionTime"`
 	Success      bool            `json:"success"`
 	ErrorMessage string          `json:"errorMessage,omitempty"`
+	LastInsertID *int64          `json:"lastInsertId,omitempty"` // rowid of the last inserted row, when the API reports it
 }
 
 func (c *Client) ExecuteQuery(accessToken string, projectID string, serverID string, query string, args []interface{}) (*QueryResponse, error) {
-	if err := validateID(projectID); err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+	req, err := c.newQueryRequest(accessToken, projectID, serverID, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, body, err := c.do(req)
+	if err != nil {
+		return nil, err
 	}
-	if err := validateID(serverID); err != nil {
-		return nil, fmt.Errorf("invalid server ID: %w", err)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, queryError(resp, body)
+	}
+
+	var rawResponse map[string]interface{}
+	if err := decodeJSON(resp, body, &rawResponse); err != nil {
+		return nil, err
+	}
+	queryResponse := parseQueryResponse(rawResponse)
+
+	logQueryPayload(resp, int64(len(body)), len(queryResponse.Rows), queryResponse.ExecutionTime, start)
+
+	return queryResponse, nil
+}
+
+// logQueryPayload logs, with Verbose, the size of a query result next to the
+// server's executionTime and the total time the client took, so a slow server
+// can be told apart from a large result that was slow to download and decode
+func logQueryPayload(resp *http.Response, size int64, rows int, executionTime int, start time.Time) {
+	if !Verbose {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[api] query result: %s, %d row(s); server execution %dms, total %dms including transfer and decoding (request id: %s)\n",
+		formatBytes(size), rows, executionTime, time.Since(start).Milliseconds(), requestID(resp))
+}
+
+// formatBytes formats a byte count for logs, e.g. 2048 as "2.0 KiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// newQueryRequest builds the request for a query
+func (c *Client) newQueryRequest(accessToken, projectID, serverID, query string, args []interface{}) (*http.Request, error) {
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
+	}
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
 	}
 	// URL encode to prevent path injection
 	encodedProjectID := url.PathEscape(projectID)
@@ -410,67 +817,37 @@ func (c *Client) ExecuteQuery(accessToken string, projectID string, serverID str
 		return nil, err
 	}
 	
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+	req, err := c.newRequest("POST", url, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to execute query: %s", string(body))
-	}
-
-	// API may return response wrapped in "result" object or directly
-	var rawResponse map[string]interface{}
-	if err := json.Unmarshal(body, &rawResponse); err != nil {
-		return nil, err
-	}
-
-	// Check if response is wrapped in "result" object (for system queries)
-	var responseData map[string]interface{}
-	if result, ok := rawResponse["result"].(map[string]interface{}); ok {
-		responseData = result
-	} else {
-		// Direct response format
-		responseData = rawResponse
-	}
+// queryError converts a non-200 query response to an error
+func queryError(resp *http.Response, body []byte) error {
+	return errorResponse(resp, body, "failed to execute query")
+}
 
-	// Convert to QueryResponse
-	queryResponse := QueryResponse{
-		Success:      true, // Default to true if we got here
-		RowsAffected: 0,
-		ExecutionTime: 0,
+// parseQueryResponse converts a decoded query result. The API sends the
+// result directly or, for system queries, wrapped in a "result" object, and
+// a failed query may come as just an errorMessage.
+func parseQueryResponse(raw map[string]interface{}) *QueryResponse {
+	data := raw
+	if result, ok := raw["result"].(map[string]interface{}); ok {
+		data = result
 	}
 
-	// Extract columns
-	if cols, ok := responseData["columns"].([]interface{}); ok {
-		queryResponse.Columns = make([]string, len(cols))
-		for i, col := range cols {
-			if str, ok := col.(string); ok {
-				queryResponse.Columns[i] = str
-			}
+	queryResponse := &QueryResponse{Success: true}
+	for _, key := range queryFields {
+		if value, ok := data[key]; ok {
+			queryResponse.setField(key, value)
 		}
 	}
-
-	// Extract rows
-	if rows, ok := responseData["rows"].([]interface{}); ok {
+	if rows, ok := data["rows"].([]interface{}); ok {
 		queryResponse.Rows = make([][]interface{}, len(rows))
 		for i, row := range rows {
 			if rowArray, ok := row.([]interface{}); ok {
@@ -478,36 +855,79 @@ func (c *Client) ExecuteQuery(accessToken string, projectID string, serverID str
 			}
 		}
 	}
+	queryResponse.settleSuccess(len(queryResponse.Rows))
+	return queryResponse
+}
 
-	// Extract execution time
-	if et, ok := responseData["executionTime"].(float64); ok {
-		queryResponse.ExecutionTime = int(et)
-	}
-
-	// Extract rows affected
-	if ra, ok := responseData["rowsAffected"].(float64); ok {
-		queryResponse.RowsAffected = int(ra)
-	}
-
-	// Extract success flag (may not be present, default to true)
-	if success, ok := responseData["success"].(bool); ok {
-		queryResponse.Success = success
-	}
-
-	// Extract error message
-	if errMsg, ok := responseData["errorMessage"].(string); ok {
-		queryResponse.ErrorMessage = errMsg
-		queryResponse.Success = false
+// settleSuccess decides Success once every field of a result is read. A
+// result without a "success" field succeeded. An errorMessage always means
+// failure, whatever "success" says, while a result without columns or rows
+// and without an errorMessage is an empty result, not a failure.
+func (r *QueryResponse) settleSuccess(rows int) {
+	switch {
+	case r.ErrorMessage != "":
+		r.Success = false
+	case len(r.Columns) == 0 && rows == 0:
+		r.Success = true
 	}
+}
 
-	// If no columns but we have rows, it might be a different format
-	// Try to handle empty result sets gracefully
-	if len(queryResponse.Columns) == 0 && len(queryResponse.Rows) == 0 {
-		// This is a valid empty result, not an error
-		queryResponse.Success = true
+// queryFields are the fields of a query result other than its rows
+var queryFields = []string{"columns", "columnTypes", "executionTime", "rowsAffected", "lastInsertId", "lastInsertRowid", "success", "errorMessage"}
+
+// setField copies one field of a query result, other than its rows, from
+// the decoded JSON. Fields of the wrong type are ignored.
+func (r *QueryResponse) setField(key string, value interface{}) {
+	switch key {
+	case "columns":
+		// Extract columns
+		if cols, ok := value.([]interface{}); ok {
+			r.Columns = make([]string, len(cols))
+			for i, col := range cols {
+				if str, ok := col.(string); ok {
+					r.Columns[i] = str
+				}
+			}
+		}
+	case "columnTypes":
+		// Extract declared column types (empty for expressions)
+		if types, ok := value.([]interface{}); ok {
+			r.ColumnTypes = make([]string, len(types))
+			for i, t := range types {
+				if str, ok := t.(string); ok {
+					r.ColumnTypes[i] = str
+				}
+			}
+		}
+	case "executionTime":
+		if et, ok := value.(float64); ok {
+			r.ExecutionTime = int(et)
+		}
+	case "rowsAffected":
+		if ra, ok := value.(float64); ok {
+			r.RowsAffected = int(ra)
+		}
+	case "lastInsertId", "lastInsertRowid":
+		// libSQL reports the rowid as a string, since it may exceed 2^53
+		switch id := value.(type) {
+		case float64:
+			rowid := int64(id)
+			r.LastInsertID = &rowid
+		case string:
+			if rowid, err := strconv.ParseInt(id, 10, 64); err == nil {
+				r.LastInsertID = &rowid
+			}
+		}
+	case "success":
+		// May not be present; settleSuccess has the last word
+		if success, ok := value.(bool); ok {
+			r.Success = success
+		}
+	case "errorMessage":
+		if errMsg, ok := value.(string); ok {
+			r.ErrorMessage = errMsg
+		}
 	}
-
-	return &queryResponse, nil
 }
 
 type CreateNameserverRequest struct {
@@ -529,15 +949,24 @@ type CreateNameserverResponse struct {
 }
 
 func (c *Client) CreateNameserver(accessToken string, projectID string, serverID string, nameserverName string) (*CreateNameserverResponse, error) {
-	if err := validateID(projectID); err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+	return c.CreateNameserverWithDatabase(accessToken, projectID, serverID, nameserverName, "", "")
+}
+
+// CreateNameserverWithDatabase creates a nameserver backed by an existing
+// libSQL database. With an empty databaseURL the API provisions a new one.
+func (c *Client) CreateNameserverWithDatabase(accessToken string, projectID string, serverID string, nameserverName string, databaseURL string, databaseToken string) (*CreateNameserverResponse, error) {
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
 	}
-	if err := validateID(serverID); err != nil {
-		return nil, fmt.Errorf("invalid server ID: %w", err)
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
 	}
 	// Validate nameserver name
-	if len(nameserverName) == 0 || len(nameserverName) > 100 {
-		return nil, fmt.Errorf("invalid nameserver name: must be 1-100 characters")
+	switch {
+	case nameserverName == "":
+		return nil, fmt.Errorf("invalid nameserver name: it is empty")
+	case len(nameserverName) > 100:
+		return nil, fmt.Errorf("invalid nameserver name: it is %d characters long, the maximum is 100", len(nameserverName))
 	}
 	// URL encode to prevent path injection
 	encodedProjectID := url.PathEscape(projectID)
@@ -545,7 +974,9 @@ func (c *Client) CreateNameserver(accessToken string, projectID string, serverID
 	url := fmt.Sprintf("%s/api/developer/projects/%s/servers/%s/databases", c.BaseURL, encodedProjectID, encodedServerID)
 	
 	reqBody := CreateNameserverRequest{
-		DatabaseName: nameserverName,
+		DatabaseName:  nameserverName,
+		DatabaseURL:   databaseURL,
+		DatabaseToken: databaseToken,
 	}
 	
 	jsonData, err := json.Marshal(reqBody)
@@ -553,7 +984,7 @@ func (c *Client) CreateNameserver(accessToken string, projectID string, serverID
 		return nil, err
 	}
 	
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+	req, err := c.newRequest("POST", url, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return nil, err
 	}
@@ -561,33 +992,74 @@ func (c *Client) CreateNameserver(accessToken string, projectID string, serverID
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to create nameserver: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to create nameserver")
 	}
 
 	var response CreateNameserverResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := decodeJSON(resp, body, &response); err != nil {
 		return nil, err
 	}
 
 	return &response, nil
 }
 
+// ReactivateNameserver marks a soft-deleted nameserver active again and
+// returns it. Its database and tables are kept while it is inactive.
+func (c *Client) ReactivateNameserver(accessToken string, projectID string, serverID string, nameserverID string) (*Database, error) {
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
+	}
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
+	}
+	if err := validateID("nameserver", nameserverID); err != nil {
+		return nil, err
+	}
+	// URL encode to prevent path injection
+	encodedProjectID := url.PathEscape(projectID)
+	encodedServerID := url.PathEscape(serverID)
+	encodedNameserverID := url.PathEscape(nameserverID)
+
+	req, err := c.newRequest("PATCH", c.BaseURL+"/api/developer/projects/"+encodedProjectID+"/servers/"+encodedServerID+"/databases/"+encodedNameserverID, strings.NewReader(`{"isActive":true}`))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorResponse(resp, body, "failed to reactivate nameserver")
+	}
+
+	// The nameserver may come wrapped as {"database": {...}} or on its own
+	var response struct {
+		Database *Database `json:"database"`
+	}
+	if err := decodeJSON(resp, body, &response); err != nil {
+		return nil, err
+	}
+	if response.Database != nil {
+		return response.Database, nil
+	}
+	var database Database
+	if err := decodeJSON(resp, body, &database); err != nil {
+		return nil, err
+	}
+	return &database, nil
+}
+
 type InitializeNameserverRequest struct {
 	SchemaType   string `json:"schemaType,omitempty"`   // 'messaging', 'analytics', or 'both'
 	DropExisting bool   `json:"dropExisting,omitempty"` // Whether to drop existing tables
@@ -611,14 +1083,14 @@ func (c *Client) InitializeNameserver(accessToken string, projectID string, serv
 }
 
 func (c *Client) InitializeNameserverWithOptions(accessToken string, projectID string, serverID string, nameserverID string, schemaType string, dropExisting bool) (*InitializeNameserverResponse, error) {
-	if err := validateID(projectID); err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+	if err := validateID("project", projectID); err != nil {
+		return nil, err
 	}
-	if err := validateID(serverID); err != nil {
-		return nil, fmt.Errorf("invalid server ID: %w", err)
+	if err := validateID("server", serverID); err != nil {
+		return nil, err
 	}
-	if err := validateID(nameserverID); err != nil {
-		return nil, fmt.Errorf("invalid nameserver ID: %w", err)
+	if err := validateID("nameserver", nameserverID); err != nil {
+		return nil, err
 	}
 	// Validate schema type
 	validTypes := map[string]bool{
@@ -646,7 +1118,7 @@ func (c *Client) InitializeNameserverWithOptions(accessToken string, projectID s
 		return nil, err
 	}
 	
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+	req, err := c.newRequest("POST", url, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return nil, err
 	}
@@ -654,27 +1126,17 @@ func (c *Client) InitializeNameserverWithOptions(accessToken string, projectID s
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return nil, &apiErr
-		}
-		return nil, fmt.Errorf("failed to initialize nameserver: %s", string(body))
+		return nil, errorResponse(resp, body, "failed to initialize nameserver")
 	}
 
 	var response InitializeNameserverResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := decodeJSON(resp, body, &response); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,515 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queryResponseCases are the shapes of query result the API sends
+var queryResponseCases = []struct {
+	name string
+	body string
+	want QueryResponse
+}{
+	{
+		name: "direct",
+		body: `{"columns":["id","title"],"rows":[["c1","Hello"],["c2",null]],"rowsAffected":0,"executionTime":4}`,
+		want: QueryResponse{
+			Columns:       []string{"id", "title"},
+			Rows:          [][]interface{}{{"c1", "Hello"}, {"c2", nil}},
+			ExecutionTime: 4,
+			Success:       true,
+		},
+	},
+	{
+		name: "wrapped in result",
+		body: `{"result":{"columns":["name"],"rows":[["messages_db"]],"executionTime":2,"success":true}}`,
+		want: QueryResponse{
+			Columns:       []string{"name"},
+			Rows:          [][]interface{}{{"messages_db"}},
+			ExecutionTime: 2,
+			Success:       true,
+		},
+	},
+	{
+		name: "error only",
+		body: `{"success":false,"errorMessage":"no such table: messages_db"}`,
+		want: QueryResponse{ErrorMessage: "no such table: messages_db"},
+	},
+	{
+		name: "error message without success field",
+		body: `{"errorMessage":"near \"SELEC\": syntax error"}`,
+		want: QueryResponse{ErrorMessage: `near "SELEC": syntax error`},
+	},
+	{
+		name: "error message overrides success",
+		body: `{"columns":["id"],"rows":[],"success":true,"errorMessage":"database is locked"}`,
+		want: QueryResponse{Columns: []string{"id"}, Rows: [][]interface{}{}, ErrorMessage: "database is locked"},
+	},
+	{
+		name: "empty error message",
+		body: `{"columns":["id"],"rows":[["a"]],"errorMessage":""}`,
+		want: QueryResponse{Columns: []string{"id"}, Rows: [][]interface{}{{"a"}}, Success: true},
+	},
+	{
+		name: "empty result",
+		body: `{}`,
+		want: QueryResponse{Success: true},
+	},
+	{
+		name: "empty result marked unsuccessful",
+		body: `{"success":false,"rows":[]}`,
+		want: QueryResponse{Rows: [][]interface{}{}, Success: true},
+	},
+	{
+		name: "columns without rows",
+		body: `{"columns":["id"],"rows":[],"executionTime":1}`,
+		want: QueryResponse{Columns: []string{"id"}, Rows: [][]interface{}{}, ExecutionTime: 1, Success: true},
+	},
+	{
+		name: "write with string rowid",
+		body: `{"rowsAffected":1,"lastInsertRowid":"9007199254740993","executionTime":3}`,
+		want: QueryResponse{RowsAffected: 1, ExecutionTime: 3, Success: true, LastInsertID: int64Ptr(9007199254740993)},
+	},
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestParseQueryResponse(t *testing.T) {
+	for _, tc := range queryResponseCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var raw map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.body), &raw); err != nil {
+				t.Fatalf("bad test body: %v", err)
+			}
+			got := parseQueryResponse(raw)
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("parseQueryResponse(%s)\n got %+v\nwant %+v", tc.body, *got, tc.want)
+			}
+		})
+	}
+}
+
+// The streamed result must agree with ExecuteQuery's for every shape
+func TestQueryStreamMatchesParseQueryResponse(t *testing.T) {
+	for _, tc := range queryResponseCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var rows [][]interface{}
+			stream := &queryStream{
+				dec: json.NewDecoder(strings.NewReader(tc.body)),
+				onRow: func(columns []string, row []interface{}) error {
+					rows = append(rows, row)
+					return nil
+				},
+				resp: &QueryResponse{Success: true},
+			}
+			if err := stream.read(); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+
+			// Streamed rows go to onRow rather than into the response
+			want := tc.want
+			want.Rows = nil
+			if !reflect.DeepEqual(*stream.resp, want) {
+				t.Errorf("stream response\n got %+v\nwant %+v", *stream.resp, want)
+			}
+			if len(rows) != len(tc.want.Rows) || (len(rows) > 0 && !reflect.DeepEqual(rows, tc.want.Rows)) {
+				t.Errorf("streamed rows = %v, want %v", rows, tc.want.Rows)
+			}
+		})
+	}
+}
+
+// newTestClient returns a client for a test server answering with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL)
+}
+
+// respond answers every request with status and body, as JSON unless the
+// body is an HTML page
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(body, "<") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+// clientCalls call each client method with valid arguments
+var clientCalls = []struct {
+	name string
+	call func(c *Client) error
+}{
+	{"InitiateDeviceCode", func(c *Client) error { _, err := c.InitiateDeviceCode(); return err }},
+	{"GetToken", func(c *Client) error { _, err := c.GetToken("ABCD1234"); return err }},
+	{"GetCurrentUser", func(c *Client) error { _, err := c.GetCurrentUser("tok"); return err }},
+	{"ListProjects", func(c *Client) error { _, err := c.ListProjects("tok"); return err }},
+	{"RenameProject", func(c *Client) error { _, err := c.RenameProject("tok", "P1", "Chat"); return err }},
+	{"DeleteProject", func(c *Client) error { return c.DeleteProject("tok", "P1") }},
+	{"ListServers", func(c *Client) error { _, err := c.ListServers("tok", "P1"); return err }},
+	{"RenameServer", func(c *Client) error {
+		name := "Main"
+		_, err := c.RenameServer("tok", "P1", "S1", UpdateServerRequest{Name: &name})
+		return err
+	}},
+	{"ListDatabases", func(c *Client) error { _, err := c.ListDatabases("tok", "P1", "S1"); return err }},
+	{"ExecuteQuery", func(c *Client) error { _, err := c.ExecuteQuery("tok", "P1", "S1", "SELECT 1", nil); return err }},
+	{"CreateNameserver", func(c *Client) error { _, err := c.CreateNameserver("tok", "P1", "S1", "db"); return err }},
+	{"ReactivateNameserver", func(c *Client) error { _, err := c.ReactivateNameserver("tok", "P1", "S1", "D1"); return err }},
+	{"InitializeNameserver", func(c *Client) error { _, err := c.InitializeNameserver("tok", "P1", "S1", "D1"); return err }},
+}
+
+func TestClientErrorResponses(t *testing.T) {
+	for _, method := range clientCalls {
+		t.Run(method.name, func(t *testing.T) {
+			t.Run("API error", func(t *testing.T) {
+				client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set(requestIDHeader, "req-1")
+					respond(http.StatusBadRequest, `{"error":"invalid_request","error_description":"bad input"}`)(w, r)
+				})
+				err := method.call(client)
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("error = %v (%T), want *APIError", err, err)
+				}
+				want := APIError{ErrorCode: "invalid_request", ErrorDescription: "bad input", StatusCode: http.StatusBadRequest, RequestID: "req-1"}
+				if *apiErr != want {
+					t.Errorf("error = %+v, want %+v", *apiErr, want)
+				}
+				if IsUnauthorized(err) {
+					t.Errorf("IsUnauthorized(%v) = true, want false", err)
+				}
+			})
+
+			t.Run("JSON without an error object", func(t *testing.T) {
+				client := newTestClient(t, respond(http.StatusInternalServerError, `["boom"]`))
+				var apiErr *APIError
+				if err := method.call(client); !errors.As(err, &apiErr) {
+					t.Fatalf("error = %v (%T), want *APIError", err, err)
+				}
+				if apiErr.ErrorCode == "" || apiErr.ErrorDescription != `["boom"]` || apiErr.StatusCode != http.StatusInternalServerError {
+					t.Errorf("error = %+v, want the fallback code with the body as description", *apiErr)
+				}
+			})
+
+			t.Run("non-JSON body", func(t *testing.T) {
+				client := newTestClient(t, respond(http.StatusBadGateway, "<html><body>Bad Gateway</body></html>"))
+				err := method.call(client)
+				var nonJSON *NonJSONResponseError
+				if !errors.As(err, &nonJSON) {
+					t.Fatalf("error = %v (%T), want *NonJSONResponseError", err, err)
+				}
+				if nonJSON.StatusCode != http.StatusBadGateway || nonJSON.ContentType != "text/html" || nonJSON.RequestID == "" {
+					t.Errorf("error = %+v, want HTTP 502, text/html and the request's ID", *nonJSON)
+				}
+			})
+
+			t.Run("unauthorized", func(t *testing.T) {
+				client := newTestClient(t, respond(http.StatusUnauthorized, `{"error":"Unauthorized","error_description":"Token expired"}`))
+				if err := method.call(client); !IsUnauthorized(err) {
+					t.Errorf("IsUnauthorized(%v) = false, want true", err)
+				}
+			})
+		})
+	}
+}
+
+// A captive portal's login page served with 200 is not a result
+func TestClientNonJSONSuccess(t *testing.T) {
+	for _, method := range clientCalls {
+		if method.name == "DeleteProject" {
+			continue // has no response body to decode
+		}
+		t.Run(method.name, func(t *testing.T) {
+			client := newTestClient(t, respond(http.StatusOK, "<html><body>Sign in to Wi-Fi</body></html>"))
+			var nonJSON *NonJSONResponseError
+			if err := method.call(client); !errors.As(err, &nonJSON) {
+				t.Fatalf("error = %v (%T), want *NonJSONResponseError", err, err)
+			}
+		})
+	}
+}
+
+func TestClientRequests(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		body   string
+	}
+	tests := []struct {
+		name     string
+		response string
+		call     func(c *Client) (interface{}, error)
+		want     interface{}
+		request  request
+	}{
+		{
+			name:     "InitiateDeviceCode",
+			response: `{"device_code":"ABCD1234","user_code":"WXYZ-1234","verification_uri":"https://example.com/device","expires_in":600,"interval":5}`,
+			call:     func(c *Client) (interface{}, error) { return c.InitiateDeviceCode() },
+			want:     &DeviceCodeResponse{DeviceCode: "ABCD1234", UserCode: "WXYZ-1234", VerificationURI: "https://example.com/device", ExpiresIn: 600, Interval: 5},
+			request:  request{"POST", "/api/cli/auth/initiate", ""},
+		},
+		{
+			name:     "ListProjects",
+			response: `{"projects":[{"id":"P1","name":"Chat","isActive":true}]}`,
+			call:     func(c *Client) (interface{}, error) { return c.ListProjects("tok") },
+			want:     &ProjectsResponse{Projects: []Project{{ID: "P1", Name: "Chat", IsActive: true}}},
+			request:  request{"GET", "/api/developer/projects", ""},
+		},
+		{
+			name:     "RenameProject wrapped",
+			response: `{"project":{"id":"P1","name":"Chat"}}`,
+			call:     func(c *Client) (interface{}, error) { return c.RenameProject("tok", "P1", "Chat") },
+			want:     &Project{ID: "P1", Name: "Chat"},
+			request:  request{"PATCH", "/api/developer/projects/P1", `{"name":"Chat"}`},
+		},
+		{
+			name:     "RenameProject unwrapped",
+			response: `{"id":"P1","name":"Chat"}`,
+			call:     func(c *Client) (interface{}, error) { return c.RenameProject("tok", "P1", "Chat") },
+			want:     &Project{ID: "P1", Name: "Chat"},
+			request:  request{"PATCH", "/api/developer/projects/P1", `{"name":"Chat"}`},
+		},
+		{
+			name:     "ListServers",
+			response: `{"servers":[{"id":"S1","name":"Main","isActive":true,"hasApiKey":true}]}`,
+			call:     func(c *Client) (interface{}, error) { return c.ListServers("tok", "P1") },
+			want:     &ServersResponse{Servers: []Server{{ID: "S1", Name: "Main", IsActive: true, HasApiKey: true}}},
+			request:  request{"GET", "/api/developer/projects/P1/servers", ""},
+		},
+		{
+			name:     "ListDatabases",
+			response: `{"databases":[{"id":"D1","databaseName":"db","isActive":false}]}`,
+			call:     func(c *Client) (interface{}, error) { return c.ListDatabases("tok", "P1", "S1") },
+			want:     &DatabasesResponse{Databases: []Database{{ID: "D1", DatabaseName: "db"}}},
+			request:  request{"GET", "/api/developer/projects/P1/servers/S1/databases", ""},
+		},
+		{
+			name:     "ExecuteQuery",
+			response: `{"columns":["n"],"rows":[[1]],"executionTime":2}`,
+			call: func(c *Client) (interface{}, error) {
+				return c.ExecuteQuery("tok", "P1", "S1", "SELECT ?", []interface{}{"S1"})
+			},
+			want:    &QueryResponse{Columns: []string{"n"}, Rows: [][]interface{}{{float64(1)}}, ExecutionTime: 2, Success: true},
+			request: request{"POST", "/api/developer/projects/P1/servers/S1/database/query", `{"query":"SELECT ?","args":["S1"]}`},
+		},
+		{
+			name:     "ExecuteQuery wrapped in result",
+			response: `{"result":{"columns":["name"],"rows":[["messages_db"]],"success":true}}`,
+			call: func(c *Client) (interface{}, error) {
+				return c.ExecuteQuery("tok", "P1", "S1", "SELECT name FROM sqlite_master", nil)
+			},
+			want:    &QueryResponse{Columns: []string{"name"}, Rows: [][]interface{}{{"messages_db"}}, Success: true},
+			request: request{"POST", "/api/developer/projects/P1/servers/S1/database/query", `{"query":"SELECT name FROM sqlite_master"}`},
+		},
+		{
+			name:     "ReactivateNameserver",
+			response: `{"database":{"id":"D1","databaseName":"db","isActive":true}}`,
+			call:     func(c *Client) (interface{}, error) { return c.ReactivateNameserver("tok", "P1", "S1", "D1") },
+			want:     &Database{ID: "D1", DatabaseName: "db", IsActive: true},
+			request:  request{"PATCH", "/api/developer/projects/P1/servers/S1/databases/D1", `{"isActive":true}`},
+		},
+		{
+			name:     "InitializeNameserverWithOptions",
+			response: `{"message":"ok","schemaType":"both","tablesCreated":6}`,
+			call: func(c *Client) (interface{}, error) {
+				return c.InitializeNameserverWithOptions("tok", "P1", "S1", "D1", "both", true)
+			},
+			want:    &InitializeNameserverResponse{Message: "ok", SchemaType: "both", TablesCreated: 6},
+			request: request{"POST", "/api/developer/projects/P1/servers/S1/databases/D1/initialize", `{"schemaType":"both","dropExisting":true}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got request
+			var auth string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = request{r.Method, r.URL.Path, string(body)}
+				auth = r.Header.Get("Authorization")
+				respond(http.StatusOK, tt.response)(w, r)
+			})
+
+			result, err := tt.call(client)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("result = %+v, want %+v", result, tt.want)
+			}
+			if got != tt.request {
+				t.Errorf("request = %+v, want %+v", got, tt.request)
+			}
+			if !strings.HasPrefix(tt.request.path, "/api/cli/") && auth != "Bearer tok" {
+				t.Errorf("Authorization = %q, want %q", auth, "Bearer tok")
+			}
+		})
+	}
+}
+
+func TestGetToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantToken string
+		wantCode  string // error code of the *APIError, "" for success
+	}{
+		{"authorized", http.StatusOK, `{"access_token":"tok","expires_in":3600,"developer":{"id":"dev1"}}`, "tok", ""},
+		{"pending", http.StatusAccepted, `{"error":"authorization_pending","error_description":"waiting"}`, "", "authorization_pending"},
+		{"pending without a body", http.StatusAccepted, ``, "", "authorization_pending"},
+		{"slow down", http.StatusAccepted, `{"error":"slow_down"}`, "", "slow_down"},
+		{"expired", http.StatusBadRequest, `{"error":"expired_token"}`, "", "expired_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deviceCode string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				deviceCode = r.URL.Query().Get("device_code")
+				respond(tt.status, tt.body)(w, r)
+			})
+
+			token, err := client.GetToken("ABCD1234")
+			if deviceCode != "ABCD1234" {
+				t.Errorf("device_code = %q, want %q", deviceCode, "ABCD1234")
+			}
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if token.AccessToken != tt.wantToken || token.Developer.ID != "dev1" {
+					t.Errorf("token = %+v, want access token %q for dev1", token, tt.wantToken)
+				}
+				return
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v (%T), want *APIError", err, err)
+			}
+			if apiErr.Code() != tt.wantCode || apiErr.StatusCode != tt.status {
+				t.Errorf("error = %+v, want code %q and status %d", *apiErr, tt.wantCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr string // "" if the ID is valid
+	}{
+		{"P1", ""},
+		{"abc_DEF-123", ""},
+		{strings.Repeat("a", 100), ""},
+		{"", "invalid server ID: it is empty"},
+		{strings.Repeat("a", 101), "invalid server ID: it is 101 characters long, the maximum is 100"},
+		{"P1/../admin", `invalid server ID "P1/../admin": it contains '/'`},
+		{"S1?x=1", `it contains '?'`},
+		{"S 1", `it contains ' '`},
+		{"S%2F1", `it contains '%'`},
+		{"sérver", `it contains 'é'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			err := validateID("server", tt.id)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateID(%q) = %v, want nil", tt.id, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateID(%q) = %v, want an error containing %q", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Arguments that fail validation are rejected before anything is sent
+func TestClientRejectsInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(c *Client) error
+		wantErr string
+	}{
+		{"short device code", func(c *Client) error { _, err := c.GetToken("ABC"); return err }, "invalid device code format"},
+		{"project ID with a slash", func(c *Client) error { _, err := c.ListServers("tok", "P1/../admin"); return err }, "invalid project ID"},
+		{"empty server ID", func(c *Client) error { _, err := c.ListDatabases("tok", "P1", ""); return err }, "invalid server ID: it is empty"},
+		{"query on a server ID with a query string", func(c *Client) error {
+			_, err := c.ExecuteQuery("tok", "P1", "S1?x=1", "SELECT 1", nil)
+			return err
+		}, "invalid server ID"},
+		{"create on a project ID with a slash", func(c *Client) error {
+			_, err := c.CreateNameserver("tok", "P1/x", "S1", "db")
+			return err
+		}, "invalid project ID"},
+		{"long nameserver name", func(c *Client) error {
+			_, err := c.CreateNameserver("tok", "P1", "S1", strings.Repeat("n", 101))
+			return err
+		}, "invalid nameserver name: it is 101 characters long"},
+		{"empty nameserver name", func(c *Client) error { _, err := c.CreateNameserver("tok", "P1", "S1", ""); return err }, "invalid nameserver name: it is empty"},
+		{"initialize with a bad nameserver ID", func(c *Client) error {
+			_, err := c.InitializeNameserver("tok", "P1", "S1", "D1;DROP")
+			return err
+		}, "invalid nameserver ID"},
+		{"unknown schema type", func(c *Client) error {
+			_, err := c.InitializeNameserverWithOptions("tok", "P1", "S1", "D1", "chat", false)
+			return err
+		}, "invalid schema type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			})
+			if err := tt.call(client); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{"columns":["n"],"rows":[[1]]}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	if got := NewClient(server.URL).HTTPClient.Timeout; got != DefaultTimeout {
+		t.Errorf("NewClient timeout = %v, want %v", got, DefaultTimeout)
+	}
+
+	client := NewClientWithTimeout(server.URL, 50*time.Millisecond)
+	_, err := client.ExecuteQuery("tok", "P1", "S1", "SELECT 1", nil)
+	if err == nil {
+		t.Fatal("ExecuteQuery succeeded, want a timeout")
+	}
+	if !IsTimeout(err) {
+		t.Errorf("IsTimeout(%v) = false, want true", err)
+	}
+
+	if IsTimeout(errors.New("connection refused")) {
+		t.Error("IsTimeout reported a timeout for an unrelated error")
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrStopStream is returned by an ExecuteQueryStream row callback to stop
+// reading the result. ExecuteQueryStream then returns without an error.
+var ErrStopStream = errors.New("stop reading query result")
+
+// RowFunc receives one row of a streamed query result with the result's
+// columns
+type RowFunc func(columns []string, row []interface{}) error
+
+// ExecuteQueryStream runs a query like ExecuteQuery, but decodes the result
+// as it is read and passes each row to onRow instead of keeping it, so
+// memory use doesn't grow with the size of the result. The returned
+// response has no Rows. If onRow returns ErrStopStream the rest of the
+// response is not read, and fields the API sends after the rows (such as
+// executionTime) may be missing.
+func (c *Client) ExecuteQueryStream(accessToken, projectID, serverID, query string, args []interface{}, onRow RowFunc) (*QueryResponse, error) {
+	req, err := c.newQueryRequest(accessToken, projectID, serverID, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, queryError(resp, body)
+	}
+
+	// Report an HTML page from a proxy as such, not as a decoding error
+	body := bufio.NewReader(resp.Body)
+	if !startsWithObject(body) {
+		data, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody))
+		return nil, nonJSONError(resp, data)
+	}
+
+	stream := &queryStream{
+		dec:   json.NewDecoder(body),
+		onRow: onRow,
+		resp:  &QueryResponse{Success: true},
+	}
+	if err := stream.read(); err != nil && !errors.Is(err, ErrStopStream) {
+		return nil, err
+	}
+	// Only what was read so far if the caller stopped early
+	logQueryPayload(resp, stream.dec.InputOffset(), stream.rows, stream.resp.ExecutionTime, start)
+	return stream.resp, nil
+}
+
+// startsWithObject reports whether the next non-space byte of r opens a JSON
+// object, leaving that byte unread
+func startsWithObject(r *bufio.Reader) bool {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		r.UnreadByte()
+		return b == '{'
+	}
+}
+
+// queryStream decodes a query result token by token. The result may be
+// wrapped in a "result" object, as for ExecuteQuery.
+type queryStream struct {
+	dec   *json.Decoder
+	onRow RowFunc
+	resp  *QueryResponse
+
+	rows     int
+	buffered [][]interface{} // rows that arrived before the columns
+}
+
+func (s *queryStream) read() error {
+	if err := s.expectDelim('{'); err != nil {
+		return err
+	}
+	if err := s.readObject(true); err != nil {
+		return err
+	}
+
+	// Rows sent before their columns, or in a result without columns
+	if err := s.flush(); err != nil {
+		return err
+	}
+	s.resp.settleSuccess(s.rows)
+	return nil
+}
+
+// readObject reads the fields of an object whose '{' was already consumed
+func (s *queryStream) readObject(top bool) error {
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		switch {
+		case key == "rows":
+			if err := s.readRows(); err != nil {
+				return err
+			}
+		case key == "result" && top:
+			tok, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok == json.Delim('{') {
+				if err := s.readObject(false); err != nil {
+					return err
+				}
+			} else if err := s.skip(tok); err != nil {
+				return err
+			}
+		default:
+			var value interface{}
+			if err := s.dec.Decode(&value); err != nil {
+				return err
+			}
+			s.resp.setField(key, value)
+			if key == "columns" {
+				if err := s.flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	_, err := s.dec.Token() // '}'
+	return err
+}
+
+// readRows reads the rows array, passing each row on as it is decoded
+func (s *queryStream) readRows() error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return s.skip(tok)
+	}
+
+	for s.dec.More() {
+		var value interface{}
+		if err := s.dec.Decode(&value); err != nil {
+			return err
+		}
+		row, _ := value.([]interface{})
+		s.rows++
+		if s.resp.Columns == nil {
+			s.buffered = append(s.buffered, row)
+			continue
+		}
+		if err := s.onRow(s.resp.Columns, row); err != nil {
+			return err
+		}
+	}
+	_, err = s.dec.Token() // ']'
+	return err
+}
+
+// flush passes on rows that were buffered while the columns were unknown
+func (s *queryStream) flush() error {
+	for len(s.buffered) > 0 {
+		row := s.buffered[0]
+		s.buffered = s.buffered[1:]
+		if err := s.onRow(s.resp.Columns, row); err != nil {
+			return err
+		}
+	}
+	s.buffered = nil
+	return nil
+}
+
+// skip discards the rest of a value whose first token was already read
+func (s *queryStream) skip(tok json.Token) error {
+	depth := 0
+	for {
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			default:
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = s.dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *queryStream) expectDelim(want json.Delim) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("unexpected query response: expected %q, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TransportConfig is how clients connect to the API. The zero value uses
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment and the system's
+// trusted CAs, like http.DefaultTransport.
+type TransportConfig struct {
+	// Proxy is an http://, https:// or socks5:// proxy URL used for every
+	// request instead of the environment's
+	Proxy string
+	// CAFile is a PEM bundle of CAs trusted in addition to the system's, e.g.
+	// the CA of a TLS-inspecting proxy
+	CAFile string
+	// Insecure skips verifying the API's TLS certificate. Only for development
+	// against a server with a self-signed certificate.
+	Insecure bool
+}
+
+// Transport is the connection config NewClient uses
+var Transport TransportConfig
+
+// roundTripper builds the transport for the config, or returns nil when the
+// default transport will do
+func (t TransportConfig) roundTripper() (http.RoundTripper, error) {
+	if t.Proxy == "" && t.CAFile == "" && !t.Insecure {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if t.Proxy != "" {
+		proxyURL, err := ParseProxyURL(t.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if t.CAFile != "" || t.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: t.Insecure}
+	}
+	if t.CAFile != "" {
+		pool, err := loadCAFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return transport, nil
+}
+
+// ParseProxyURL checks a proxy URL. A bare host:port is taken as an HTTP
+// proxy.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "http://" + trimmed
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// loadCAFile returns the system's trusted CAs plus those in a PEM file
+func loadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s has no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockTimeout is how long to wait for another flux-relay process to
+	// finish writing the config
+	lockTimeout = 5 * time.Second
+	// staleLockAge is when a lockfile is assumed to be left over from a
+	// process that crashed while holding it
+	staleLockAge   = 30 * time.Second
+	lockRetryDelay = 20 * time.Millisecond
+)
+
+// lock takes an advisory lock on the config file by creating config.json.lock
+// next to it, so concurrent processes don't interleave read-modify-write
+// cycles. The returned function releases the lock.
+func (cm *ConfigManager) lock() (func(), error) {
+	if err := os.MkdirAll(cm.ConfigDir(), 0700); err != nil {
+		return nil, err
+	}
+
+	lockPath := cm.configPath + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("config is locked by another flux-relay process (remove %s if none is running)", lockPath)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers see either the old or the new contents
+// and never a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if err := writeAndSync(tmp, data, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+func writeAndSync(f *os.File, data []byte, perm os.FileMode) error {
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// UpdateStateFile rewrites name, a file in the config directory, with what
+// update returns for its current contents (nil if it doesn't exist yet). The
+// config lock is held for the whole read-modify-write cycle and the file is
+// replaced atomically, so concurrent commands neither lose each other's
+// changes nor see a partly written file.
+func (cm *ConfigManager) UpdateStateFile(name string, update func(data []byte) ([]byte, error)) error {
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := filepath.Join(cm.ConfigDir(), name)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	updated, err := update(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, updated, 0600)
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,19 +22,56 @@ type Config struct {
 	SelectedProject   string    `json:"selected_project,omitempty"`
 	SelectedServer    string    `json:"selected_server,omitempty"`
 	SelectedNameserver string    `json:"selected_nameserver,omitempty"`
+	// SchemaTypes is the schema type each nameserver was last initialized
+	// with, by nameserver ID
+	SchemaTypes       map[string]string `json:"schema_types,omitempty"`
 }
 
 type ConfigManager struct {
 	configPath string
+	// defaultDir is set when the config is in ~/.flux-relay rather than a
+	// directory the user named, so RemoveAll may delete the directory itself
+	defaultDir bool
 }
 
-func New() *ConfigManager {
+// DirEnv is the environment variable that overrides the config directory
+const DirEnv = "FLUX_RELAY_CONFIG_DIR"
+
+// Dir overrides the config directory New uses, e.g. from --config-dir. It
+// takes precedence over DirEnv.
+var Dir string
+
+// DefaultDir returns the config directory: Dir, then $FLUX_RELAY_CONFIG_DIR,
+// then ~/.flux-relay
+func DefaultDir() string {
+	if Dir != "" {
+		return Dir
+	}
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir
+	}
 	home, _ := os.UserHomeDir()
-	configDir := filepath.Join(home, ".flux-relay")
-	configPath := filepath.Join(configDir, "config.json")
+	return filepath.Join(home, ".flux-relay")
+}
+
+// New returns a ConfigManager for the config in DefaultDir
+func New() *ConfigManager {
+	cm := NewWithDir(DefaultDir())
+	cm.defaultDir = Dir == "" && os.Getenv(DirEnv) == ""
+	return cm
+}
+
+// NewWithDir returns a ConfigManager for the config in dir, which also holds
+// the CLI's other state such as the query history
+func NewWithDir(dir string) *ConfigManager {
+	return NewWithPath(filepath.Join(dir, "config.json"))
+}
 
+// NewWithPath returns a ConfigManager for the config file at path. Other CLI
+// state is kept next to it.
+func NewWithPath(path string) *ConfigManager {
 	return &ConfigManager{
-		configPath: configPath,
+		configPath: path,
 	}
 }
 
@@ -40,7 +79,8 @@ func (cm *ConfigManager) ConfigPath() string {
 	return cm.configPath
 }
 
-func (cm *ConfigManager) GetToken() (*Config, error) {
+// Load reads the config file without checking whether the token has expired
+func (cm *ConfigManager) Load() (*Config, error) {
 	data, err := os.ReadFile(cm.configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -54,115 +94,307 @@ func (cm *ConfigManager) GetToken() (*Config, error) {
 		return nil, err
 	}
 
+	return &config, nil
+}
+
+// ParseConfig decodes the contents of a config file and checks them against
+// the Config fields: unknown fields, values of the wrong type, an invalid API
+// URL and selections without the project or server they belong to are errors.
+func ParseConfig(data []byte) (*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the config object")
+	}
+
+	if config.APIURL != "" {
+		if _, err := api.NormalizeBaseURL(config.APIURL); err != nil {
+			return nil, fmt.Errorf("api_url: %w", err)
+		}
+	}
+	if config.SelectedServer != "" && config.SelectedProject == "" {
+		return nil, fmt.Errorf("selected_server is set but selected_project is not")
+	}
+	if config.SelectedNameserver != "" && config.SelectedServer == "" {
+		return nil, fmt.Errorf("selected_nameserver is set but selected_server is not")
+	}
+	return &config, nil
+}
+
+// ErrConfigChanged is returned by Replace when the config file no longer
+// holds what the caller read
+var ErrConfigChanged = errors.New("the config file was changed by another command")
+
+// Replace writes config over the config file, provided the file still holds
+// previous, so changes made meanwhile by another command are not lost
+func (cm *ConfigManager) Replace(previous []byte, config *Config) error {
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(cm.configPath)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, previous) {
+		return ErrConfigChanged
+	}
+	return cm.save(config)
+}
+
+func (cm *ConfigManager) GetToken() (*Config, error) {
+	config, err := cm.Load()
+	if err != nil || config == nil {
+		return nil, err
+	}
+
 	// Check if token is expired
 	if time.Now().After(config.ExpiresAt) {
 		return nil, fmt.Errorf("token expired")
 	}
 
-	return &config, nil
+	return config, nil
 }
 
-func (cm *ConfigManager) SaveToken(token *api.TokenResponse) error {
+// SaveToken saves a login's token together with apiURL, the API that issued
+// it, so later commands send the token back there and nowhere else. Saved
+// selections are restored when the same developer logs back in to the same
+// API.
+func (cm *ConfigManager) SaveToken(token *api.TokenResponse, apiURL string) error {
 	// Create config directory if it doesn't exist
 	configDir := filepath.Dir(cm.configPath)
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return err
 	}
 
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Calculate expiration time
 	expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
 
+	if normalized, err := api.NormalizeBaseURL(apiURL); err == nil {
+		apiURL = normalized
+	}
+
 	config := Config{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		ExpiresAt:    expiresAt,
 		DeveloperID:  token.Developer.ID,
 		Email:        token.Developer.Email,
+		APIURL:       apiURL,
 	}
 
+	if previous, err := cm.Load(); err == nil && previous != nil {
+		// Restore selections kept by ClearToken when the same developer logs
+		// back in; IDs from another API mean nothing here
+		if previous.AccessToken == "" && previous.DeveloperID == token.Developer.ID && previous.APIURL == apiURL {
+			config.SelectedProject = previous.SelectedProject
+			config.SelectedServer = previous.SelectedServer
+			config.SelectedNameserver = previous.SelectedNameserver
+			config.SchemaTypes = previous.SchemaTypes
+		}
+	}
+
+	return cm.save(&config)
+}
+
+// save writes the config file atomically. Callers that read the config
+// before saving must hold the lock for the whole cycle.
+func (cm *ConfigManager) save(config *Config) error {
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
 	// Write with secure permissions (read/write for owner only)
-	return os.WriteFile(cm.configPath, data, 0600)
+	return writeFileAtomic(cm.configPath, data, 0600)
 }
 
 func (cm *ConfigManager) RemoveToken() error {
 	if _, err := os.Stat(cm.configPath); os.IsNotExist(err) {
 		return nil // File doesn't exist, nothing to remove
 	}
+
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	return os.Remove(cm.configPath)
 }
 
-func (cm *ConfigManager) GetAccessToken() string {
-	config, err := cm.GetToken()
+// ClearToken removes the stored credentials but keeps the selections and the
+// developer they belong to, so logging in again as that developer restores them
+func (cm *ConfigManager) ClearToken() error {
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := cm.Load()
 	if err != nil || config == nil {
-		return ""
+		return err
 	}
-	return config.AccessToken
+
+	config.AccessToken = ""
+	config.RefreshToken = ""
+	config.ExpiresAt = time.Time{}
+
+	return cm.save(config)
 }
 
-func (cm *ConfigManager) GetSelectedProject() string {
-	config, err := cm.GetToken()
-	if err != nil || config == nil {
-		return ""
+// ConfigDir returns the directory holding the config file and other CLI state
+func (cm *ConfigManager) ConfigDir() string {
+	return filepath.Dir(cm.configPath)
+}
+
+// stateFiles are the files the CLI keeps in the config directory besides
+// config.json
+var stateFiles = []string{"config.yaml", "query_history.jsonl", "audit.log", "snippets.json"}
+
+// OwnedFiles returns the paths of the files in the config directory that the
+// CLI created: config.json, its lockfile, the other state files and temporary
+// files left by an interrupted write. Only files that exist are returned.
+func (cm *ConfigManager) OwnedFiles() ([]string, error) {
+	dir := cm.ConfigDir()
+	names := append([]string{filepath.Base(cm.configPath), filepath.Base(cm.configPath) + ".lock"}, stateFiles...)
+	patterns := []string{"config-edit-*.json"}
+	for _, name := range names {
+		patterns = append(patterns, "."+name+".*.tmp")
 	}
-	return config.SelectedProject
+
+	var files []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Lstat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
 }
 
-func (cm *ConfigManager) SetSelectedProject(projectID string) error {
-	config, err := cm.GetToken()
+// RemoveAll deletes the files the CLI keeps in the config directory (see
+// OwnedFiles). Anything else in it is left alone. The directory itself is
+// only removed if it is ~/.flux-relay and nothing else is left in it, never
+// when it was named with Dir, DirEnv or NewWithDir.
+func (cm *ConfigManager) RemoveAll() error {
+	files, err := cm.OwnedFiles()
 	if err != nil {
-		return fmt.Errorf("not logged in: %w", err)
+		return err
 	}
-	if config == nil {
-		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
 
-	config.SelectedProject = projectID
-	// Clear server and nameserver when changing project
-	config.SelectedServer = ""
-	config.SelectedNameserver = ""
-
-	data, err := json.MarshalIndent(config, "", "  ")
+	if !cm.defaultDir {
+		return nil
+	}
+	entries, err := os.ReadDir(cm.ConfigDir())
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return err
 	}
-
-	return os.WriteFile(cm.configPath, data, 0600)
+	if len(entries) > 0 {
+		return nil
+	}
+	return os.Remove(cm.ConfigDir())
 }
 
-func (cm *ConfigManager) GetSelectedServer() string {
+func (cm *ConfigManager) GetAccessToken() string {
 	config, err := cm.GetToken()
 	if err != nil || config == nil {
 		return ""
 	}
-	return config.SelectedServer
+	return config.AccessToken
 }
 
-func (cm *ConfigManager) SetSelectedServer(serverID string) error {
-	config, err := cm.GetToken()
-	if err != nil {
-		return fmt.Errorf("not logged in: %w", err)
+// GetAPIURL returns the API URL the saved token was issued by, or "" if none
+// is saved. Unlike the selections it doesn't require a valid login.
+func (cm *ConfigManager) GetAPIURL() string {
+	config, err := cm.Load()
+	if err != nil || config == nil {
+		return ""
 	}
-	if config == nil {
-		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
+	return config.APIURL
+}
+
+// SetAPIURL saves the API URL the stored token belongs to. An empty URL
+// removes it.
+func (cm *ConfigManager) SetAPIURL(apiURL string) error {
+	return cm.updateSelection(func(config *Config) {
+		config.APIURL = apiURL
+	})
+}
+
+// Selections are the project, server and nameserver the commands operate on.
+// They are stored next to the token in config.json and require a valid login.
+// Setters read the whole config, change only the selection fields and write
+// it back, so the token and other fields are never lost.
+
+// GetSelectedProject returns the selected project ID, or "" if none is
+// selected or the token is missing or expired
+func (cm *ConfigManager) GetSelectedProject() string {
+	config, err := cm.GetToken()
+	if err != nil || config == nil {
+		return ""
 	}
+	return config.SelectedProject
+}
 
-	config.SelectedServer = serverID
-	// Clear nameserver when changing server
-	config.SelectedNameserver = ""
+// SetSelectedProject selects a project. The server and nameserver belong to
+// the previous project, so they are cleared.
+func (cm *ConfigManager) SetSelectedProject(projectID string) error {
+	return cm.updateSelection(func(config *Config) {
+		config.SelectedProject = projectID
+		config.SelectedServer = ""
+		config.SelectedNameserver = ""
+	})
+}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+// GetSelectedServer returns the selected server ID, or "" if none is selected
+func (cm *ConfigManager) GetSelectedServer() string {
+	config, err := cm.GetToken()
+	if err != nil || config == nil {
+		return ""
 	}
+	return config.SelectedServer
+}
 
-	return os.WriteFile(cm.configPath, data, 0600)
+// SetSelectedServer selects a server in the selected project and clears the
+// nameserver, which belongs to the previous server
+func (cm *ConfigManager) SetSelectedServer(serverID string) error {
+	return cm.updateSelection(func(config *Config) {
+		config.SelectedServer = serverID
+		config.SelectedNameserver = ""
+	})
 }
 
+// GetSelectedNameserver returns the selected nameserver ID, or "" if none is selected
 func (cm *ConfigManager) GetSelectedNameserver() string {
 	config, err := cm.GetToken()
 	if err != nil || config == nil {
@@ -171,7 +403,42 @@ func (cm *ConfigManager) GetSelectedNameserver() string {
 	return config.SelectedNameserver
 }
 
+// SetSelectedNameserver selects a nameserver in the selected server
 func (cm *ConfigManager) SetSelectedNameserver(nameserverID string) error {
+	return cm.updateSelection(func(config *Config) {
+		config.SelectedNameserver = nameserverID
+	})
+}
+
+// GetSchemaType returns the schema type a nameserver was last initialized
+// with ("messaging", "analytics" or "both"), or "" if it isn't known
+func (cm *ConfigManager) GetSchemaType(nameserverID string) string {
+	config, err := cm.GetToken()
+	if err != nil || config == nil {
+		return ""
+	}
+	return config.SchemaTypes[nameserverID]
+}
+
+// SetSchemaType saves the schema type a nameserver was initialized with
+func (cm *ConfigManager) SetSchemaType(nameserverID, schemaType string) error {
+	return cm.updateSelection(func(config *Config) {
+		if config.SchemaTypes == nil {
+			config.SchemaTypes = make(map[string]string)
+		}
+		config.SchemaTypes[nameserverID] = schemaType
+	})
+}
+
+// updateSelection applies change to the saved config and writes it back.
+// It fails if nobody is logged in.
+func (cm *ConfigManager) updateSelection(change func(config *Config)) error {
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	config, err := cm.GetToken()
 	if err != nil {
 		return fmt.Errorf("not logged in: %w", err)
@@ -180,12 +447,27 @@ func (cm *ConfigManager) SetSelectedNameserver(nameserverID string) error {
 		return fmt.Errorf("not logged in. Run 'flux-relay login' first")
 	}
 
-	config.SelectedNameserver = nameserverID
+	change(config)
+	return cm.save(config)
+}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
+// ClearSelectedProject removes the selected project, and with it the selected
+// server and nameserver
+func (cm *ConfigManager) ClearSelectedProject() error {
+	return cm.SetSelectedProject("")
+}
+
+// ClearSelectedServer removes the selected server and nameserver
+func (cm *ConfigManager) ClearSelectedServer() error {
+	return cm.SetSelectedServer("")
+}
+
+// ClearSelectedNameserver removes the selected nameserver
+func (cm *ConfigManager) ClearSelectedNameserver() error {
+	return cm.SetSelectedNameserver("")
+}
 
-	return os.WriteFile(cm.configPath, data, 0600)
+// ClearSelections removes all selections but keeps the saved token
+func (cm *ConfigManager) ClearSelections() error {
+	return cm.ClearSelectedProject()
 }
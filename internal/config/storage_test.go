@@ -0,0 +1,523 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+)
+
+// newTestManager returns a ConfigManager whose config lives in a temporary
+// directory
+func newTestManager(t *testing.T) *ConfigManager {
+	t.Helper()
+	return NewWithDir(t.TempDir())
+}
+
+func TestDefaultDirPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(DirEnv, "")
+	t.Cleanup(func() { Dir = "" })
+
+	if got, want := DefaultDir(), filepath.Join(home, ".flux-relay"); got != want {
+		t.Errorf("DefaultDir() = %q, want %q", got, want)
+	}
+
+	envDir := t.TempDir()
+	t.Setenv(DirEnv, envDir)
+	if got := DefaultDir(); got != envDir {
+		t.Errorf("DefaultDir() with %s = %q, want %q", DirEnv, got, envDir)
+	}
+
+	Dir = t.TempDir()
+	if got := DefaultDir(); got != Dir {
+		t.Errorf("DefaultDir() with Dir set = %q, want %q", got, Dir)
+	}
+	if got, want := New().ConfigPath(), filepath.Join(Dir, "config.json"); got != want {
+		t.Errorf("New().ConfigPath() = %q, want %q", got, want)
+	}
+}
+
+// SaveToken must accept the TokenResponse of the module's own api package;
+// this fails to compile if the two packages are imported under different paths.
+func TestSaveTokenAcceptsAPITokenResponse(t *testing.T) {
+	cm := newTestManager(t)
+
+	token := &api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}
+	token.Developer.ID = "dev1"
+	token.Developer.Email = "dev@example.com"
+
+	if err := cm.SaveToken(token, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if got := cm.GetAccessToken(); got != "tok" {
+		t.Errorf("GetAccessToken() = %q, want %q", got, "tok")
+	}
+}
+
+func TestSaveTokenRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+	cm := NewWithPath(path)
+
+	token := &api.TokenResponse{AccessToken: "tok", RefreshToken: "refresh", ExpiresIn: 3600}
+	token.Developer.ID = "dev1"
+	token.Developer.Email = "dev@example.com"
+	before := time.Now()
+	if err := cm.SaveToken(token, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	// A fresh manager for the same file reads the token back
+	stored, err := NewWithPath(path).GetToken()
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	if stored == nil {
+		t.Fatal("GetToken() = nil, want the saved token")
+	}
+	if stored.AccessToken != "tok" || stored.RefreshToken != "refresh" || stored.DeveloperID != "dev1" || stored.Email != "dev@example.com" {
+		t.Errorf("GetToken() = %+v, want the saved fields", stored)
+	}
+	if earliest := before.Add(time.Hour); stored.ExpiresAt.Before(earliest) || stored.ExpiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want an hour after saving", stored.ExpiresAt)
+	}
+}
+
+func TestGetToken(t *testing.T) {
+	valid := time.Now().Add(time.Hour).Format(time.RFC3339)
+	expired := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	tests := []struct {
+		name      string
+		data      string // "" for no config file
+		wantToken string
+		wantErr   bool
+	}{
+		{"missing file", "", "", false},
+		{"valid token", `{"access_token": "tok", "expires_at": "` + valid + `"}`, "tok", false},
+		{"expired token", `{"access_token": "tok", "expires_at": "` + expired + `"}`, "", true},
+		{"no expiry", `{"access_token": "tok"}`, "", true},
+		{"corrupt JSON", `{"access_token": "tok",`, "", true},
+		{"wrong type", `{"access_token": 42}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.data != "" {
+				if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+			cm := NewWithPath(path)
+
+			config, err := cm.GetToken()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if config != nil {
+				got = config.AccessToken
+			}
+			if got != tt.wantToken {
+				t.Errorf("GetToken() token = %q, want %q", got, tt.wantToken)
+			}
+			if got := cm.GetAccessToken(); got != tt.wantToken {
+				t.Errorf("GetAccessToken() = %q, want %q", got, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestSaveTokenPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), ".flux-relay")
+	cm := NewWithDir(dir)
+	if err := cm.SaveToken(&api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	for _, tt := range []struct {
+		path string
+		want os.FileMode
+	}{
+		{dir, 0700},
+		{cm.ConfigPath(), 0600},
+	} {
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if got := info.Mode().Perm(); got != tt.want {
+			t.Errorf("%s has mode %o, want %o", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveToken(t *testing.T) {
+	cm := loginTestManager(t)
+	if err := cm.RemoveToken(); err != nil {
+		t.Fatalf("RemoveToken: %v", err)
+	}
+	if _, err := os.Stat(cm.ConfigPath()); !os.IsNotExist(err) {
+		t.Errorf("config file still exists after RemoveToken (stat error %v)", err)
+	}
+	if got := cm.GetAccessToken(); got != "" {
+		t.Errorf("GetAccessToken() = %q after RemoveToken, want empty", got)
+	}
+
+	// Removing again, with no file left, is not an error
+	if err := cm.RemoveToken(); err != nil {
+		t.Errorf("RemoveToken without a config file: %v", err)
+	}
+}
+
+// loginTestManager returns a test ConfigManager with a saved token
+func loginTestManager(t *testing.T) *ConfigManager {
+	t.Helper()
+	cm := newTestManager(t)
+	token := &api.TokenResponse{AccessToken: "tok", RefreshToken: "refresh", ExpiresIn: 3600}
+	token.Developer.ID = "dev1"
+	if err := cm.SaveToken(token, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	return cm
+}
+
+// writeStateFiles fills dir with every file the CLI keeps there and a
+// foreign file the user put there, and returns the foreign file's path
+func writeStateFiles(t *testing.T, cm *ConfigManager) string {
+	t.Helper()
+	token := &api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}
+	if err := cm.SaveToken(token, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	for _, name := range append(stateFiles, "config.json.lock", ".config.json.123.tmp") {
+		if err := os.WriteFile(filepath.Join(cm.ConfigDir(), name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	foreign := filepath.Join(cm.ConfigDir(), "thesis.txt")
+	if err := os.WriteFile(foreign, []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return foreign
+}
+
+func TestRemoveAllKeepsForeignFiles(t *testing.T) {
+	cm := newTestManager(t)
+	foreign := writeStateFiles(t, cm)
+
+	if err := cm.RemoveAll(); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+
+	if data, err := os.ReadFile(foreign); err != nil || string(data) != "keep me" {
+		t.Errorf("foreign file after RemoveAll: %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(cm.ConfigDir())
+	if err != nil {
+		t.Fatalf("config directory was removed: %v", err)
+	}
+	if len(entries) != 1 {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		t.Errorf("left in the config directory: %v, want only thesis.txt", names)
+	}
+}
+
+func TestRemoveAllRemovesOnlyEmptyDefaultDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(DirEnv, "")
+
+	cm := New()
+	if err := cm.SaveToken(&api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if err := cm.RemoveAll(); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := os.Stat(cm.ConfigDir()); !os.IsNotExist(err) {
+		t.Errorf("empty ~/.flux-relay was kept: %v", err)
+	}
+
+	// A directory named by the user stays, even when empty
+	dir := t.TempDir()
+	t.Setenv(DirEnv, dir)
+	cm = New()
+	if err := cm.SaveToken(&api.TokenResponse{AccessToken: "tok", ExpiresIn: 3600}, ""); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if err := cm.RemoveAll(); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("directory from %s was removed: %v", DirEnv, err)
+	}
+}
+
+func TestSetSelectionsPersistAndKeepToken(t *testing.T) {
+	cm := loginTestManager(t)
+
+	if err := cm.SetSelectedProject("P1"); err != nil {
+		t.Fatalf("SetSelectedProject: %v", err)
+	}
+	if err := cm.SetSelectedServer("S1"); err != nil {
+		t.Fatalf("SetSelectedServer: %v", err)
+	}
+	if err := cm.SetSelectedNameserver("D1"); err != nil {
+		t.Fatalf("SetSelectedNameserver: %v", err)
+	}
+
+	// A fresh manager reads the selections back from disk
+	reloaded := NewWithDir(cm.ConfigDir())
+	if got := reloaded.GetSelectedProject(); got != "P1" {
+		t.Errorf("GetSelectedProject() = %q, want %q", got, "P1")
+	}
+	if got := reloaded.GetSelectedServer(); got != "S1" {
+		t.Errorf("GetSelectedServer() = %q, want %q", got, "S1")
+	}
+	if got := reloaded.GetSelectedNameserver(); got != "D1" {
+		t.Errorf("GetSelectedNameserver() = %q, want %q", got, "D1")
+	}
+
+	stored, err := reloaded.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if stored.AccessToken != "tok" || stored.RefreshToken != "refresh" || stored.DeveloperID != "dev1" {
+		t.Errorf("token fields changed: %+v", stored)
+	}
+}
+
+func TestSetSelectedProjectClearsServerAndNameserver(t *testing.T) {
+	cm := loginTestManager(t)
+	cm.SetSelectedProject("P1")
+	cm.SetSelectedServer("S1")
+	cm.SetSelectedNameserver("D1")
+
+	if err := cm.SetSelectedProject("P2"); err != nil {
+		t.Fatalf("SetSelectedProject: %v", err)
+	}
+	if got := cm.GetSelectedServer(); got != "" {
+		t.Errorf("GetSelectedServer() = %q, want empty", got)
+	}
+	if got := cm.GetSelectedNameserver(); got != "" {
+		t.Errorf("GetSelectedNameserver() = %q, want empty", got)
+	}
+}
+
+func TestSetSelectedServerClearsNameserver(t *testing.T) {
+	cm := loginTestManager(t)
+	cm.SetSelectedProject("P1")
+	cm.SetSelectedServer("S1")
+	cm.SetSelectedNameserver("D1")
+
+	if err := cm.SetSelectedServer("S2"); err != nil {
+		t.Fatalf("SetSelectedServer: %v", err)
+	}
+	if got := cm.GetSelectedProject(); got != "P1" {
+		t.Errorf("GetSelectedProject() = %q, want %q", got, "P1")
+	}
+	if got := cm.GetSelectedNameserver(); got != "" {
+		t.Errorf("GetSelectedNameserver() = %q, want empty", got)
+	}
+}
+
+func TestSetSelectionRequiresLogin(t *testing.T) {
+	cm := newTestManager(t)
+
+	if err := cm.SetSelectedProject("P1"); err == nil {
+		t.Error("SetSelectedProject succeeded without a saved token")
+	}
+	if got := cm.GetSelectedProject(); got != "" {
+		t.Errorf("GetSelectedProject() = %q, want empty", got)
+	}
+}
+
+func TestSchemaTypesPersistPerNameserver(t *testing.T) {
+	cm := loginTestManager(t)
+	if got := cm.GetSchemaType("D1"); got != "" {
+		t.Errorf("GetSchemaType() before any save = %q, want empty", got)
+	}
+
+	if err := cm.SetSchemaType("D1", "analytics"); err != nil {
+		t.Fatalf("SetSchemaType: %v", err)
+	}
+	if err := cm.SetSchemaType("D2", "messaging"); err != nil {
+		t.Fatalf("SetSchemaType: %v", err)
+	}
+	// Selecting another nameserver doesn't forget them
+	if err := cm.SetSelectedProject("P2"); err != nil {
+		t.Fatalf("SetSelectedProject: %v", err)
+	}
+
+	reloaded := NewWithDir(cm.ConfigDir())
+	if got := reloaded.GetSchemaType("D1"); got != "analytics" {
+		t.Errorf("GetSchemaType(D1) = %q, want %q", got, "analytics")
+	}
+	if got := reloaded.GetSchemaType("D2"); got != "messaging" {
+		t.Errorf("GetSchemaType(D2) = %q, want %q", got, "messaging")
+	}
+}
+
+func TestSaveTokenSavesLoginAPIURL(t *testing.T) {
+	cm := loginTestManager(t)
+	if err := cm.SetAPIURL("https://relay.example.com"); err != nil {
+		t.Fatalf("SetAPIURL: %v", err)
+	}
+	cm.SetSelectedProject("P1")
+	if err := cm.ClearToken(); err != nil {
+		t.Fatalf("ClearToken: %v", err)
+	}
+
+	// Logging in to another API must not leave the old URL for the new token
+	token := &api.TokenResponse{AccessToken: "tok2", ExpiresIn: 3600}
+	token.Developer.ID = "dev1"
+	if err := cm.SaveToken(token, "https://other.example.com/"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if got := cm.GetAPIURL(); got != "https://other.example.com" {
+		t.Errorf("GetAPIURL() = %q, want %q", got, "https://other.example.com")
+	}
+	if got := cm.GetAccessToken(); got != "tok2" {
+		t.Errorf("GetAccessToken() = %q, want %q", got, "tok2")
+	}
+	if got := cm.GetSelectedProject(); got != "" {
+		t.Errorf("GetSelectedProject() = %q, want selections from another API dropped", got)
+	}
+
+	// Logging back in to the same API restores the selections
+	cm.SetSelectedProject("P2")
+	if err := cm.ClearToken(); err != nil {
+		t.Fatalf("ClearToken: %v", err)
+	}
+	if err := cm.SaveToken(token, "https://other.example.com"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if got := cm.GetSelectedProject(); got != "P2" {
+		t.Errorf("GetSelectedProject() = %q, want %q", got, "P2")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"access_token": "tok", "selected_project": "P1", "selected_server": "S1"}`, false},
+		{"unknown field", `{"access_token": "tok", "selected_projct": "P1"}`, true},
+		{"wrong type", `{"access_token": 42}`, true},
+		{"syntax error", `{"access_token": "tok",}`, true},
+		{"trailing data", `{"access_token": "tok"} {}`, true},
+		{"invalid API URL", `{"api_url": "relay.example.com"}`, true},
+		{"server without project", `{"selected_server": "S1"}`, true},
+		{"nameserver without server", `{"selected_project": "P1", "selected_nameserver": "D1"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplaceRefusesChangedConfig(t *testing.T) {
+	cm := loginTestManager(t)
+	previous, err := os.ReadFile(cm.ConfigPath())
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := cm.SetSelectedProject("P1"); err != nil {
+		t.Fatalf("SetSelectedProject: %v", err)
+	}
+
+	edited, err := ParseConfig(previous)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	edited.SelectedProject = "P2"
+	if err := cm.Replace(previous, edited); err != ErrConfigChanged {
+		t.Fatalf("Replace() error = %v, want ErrConfigChanged", err)
+	}
+	if got := cm.GetSelectedProject(); got != "P1" {
+		t.Errorf("GetSelectedProject() = %q, want %q", got, "P1")
+	}
+
+	current, err := os.ReadFile(cm.ConfigPath())
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := cm.Replace(current, edited); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if got := cm.GetSelectedProject(); got != "P2" {
+		t.Errorf("GetSelectedProject() = %q, want %q", got, "P2")
+	}
+}
+
+func TestConcurrentSelectionWritesKeepConfigValid(t *testing.T) {
+	cm := loginTestManager(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := NewWithDir(cm.ConfigDir()).SetSelectedNameserver(fmt.Sprintf("D%d", i)); err != nil {
+				t.Errorf("SetSelectedNameserver: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := cm.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if stored.AccessToken != "tok" || stored.SelectedNameserver == "" {
+		t.Errorf("unexpected config after concurrent writes: %+v", stored)
+	}
+}
+
+func TestConcurrentStateFileUpdatesKeepEveryChange(t *testing.T) {
+	cm := newTestManager(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := NewWithDir(cm.ConfigDir()).UpdateStateFile("state.txt", func(data []byte) ([]byte, error) {
+				return append(data, fmt.Sprintf("%d\n", i)...), nil
+			})
+			if err != nil {
+				t.Errorf("UpdateStateFile: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(filepath.Join(cm.ConfigDir(), "state.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 20 {
+		t.Errorf("state file has %d lines after 20 updates, want 20:\n%s", lines, data)
+	}
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+)
+
+// Cells arrive from the API as decoded JSON: nil, string, float64, bool, or a
+// nested []interface{} / map[string]interface{}. Columns holding JSON text
+// (e.g. reactions TEXT DEFAULT '[]') arrive as strings instead. The helpers
+// below are the only places cells are converted, so table, CSV and JSON
+// output agree and JSON text is never encoded twice.
+
+// QueryJSON is the structured form of a query result for JSON output
+type QueryJSON struct {
+	Columns       []string    `json:"columns"`
+	ColumnTypes   []string    `json:"columnTypes"`
+	Rows          interface{} `json:"rows"`
+	RowsAffected  int         `json:"rowsAffected"`
+	LastInsertID  *int64      `json:"lastInsertId,omitempty"`
+	ExecutionTime int         `json:"executionTime"`
+	Timestamp     string      `json:"timestamp,omitempty"` // set in sql --watch mode
+}
+
+// jsonObjectRow marshals a row as a JSON object with keys in column order
+type jsonObjectRow struct {
+	columns []string
+	values  []interface{}
+}
+
+func (r jsonObjectRow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, col := range r.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		var val interface{}
+		if i < len(r.values) {
+			val = r.values[i]
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// displayCell formats a cell for table output. NULLs and empty strings are
+// shown with the markers from opts, timestamps in opts.TimeZone if set, and
+// other values as in CellText.
+func displayCell(val interface{}, opts RenderOptions) string {
+	if val == nil {
+		return opts.NullString
+	}
+	if str, ok := val.(string); ok && opts.TimeZone != nil {
+		val = convertTimestamp(str, opts.TimeZone)
+	}
+	if str := CellText(val); str != "" {
+		return str
+	}
+	return opts.EmptyString
+}
+
+// CellText returns the plain text of a non-NULL cell. Strings are returned
+// verbatim and nested values as compact JSON.
+func CellText(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+// jsonCellValue converts a cell to its native JSON value. Strings holding a
+// JSON object or array are decoded so they aren't emitted as escaped strings.
+func jsonCellValue(val interface{}) interface{} {
+	if str, ok := val.(string); ok {
+		trimmed := strings.TrimSpace(str)
+		if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+			return json.RawMessage(trimmed)
+		}
+	}
+	return val
+}
+
+// cellType returns the SQLite storage class a cell most likely came from.
+// The API doesn't report column types, so they are inferred from the decoded
+// JSON value: whole numbers are INTEGER and other numbers REAL. NULL cells
+// return "".
+func cellType(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "INTEGER"
+		}
+		return "REAL"
+	case bool:
+		return "INTEGER"
+	default:
+		// Strings, and JSON text the API already decoded
+		return "TEXT"
+	}
+}
+
+// inferColumnTypes returns a type per column. Declared types reported by the
+// API are used as-is; other columns are inferred from the values in their
+// rows. A column mixing INTEGER and REAL is REAL, one mixing anything else is
+// ANY, and one holding only NULLs (or no rows) is NULL.
+func inferColumnTypes(resp *api.QueryResponse) []string {
+	types := make([]string, len(resp.Columns))
+	for i := range types {
+		if i < len(resp.ColumnTypes) && resp.ColumnTypes[i] != "" {
+			types[i] = strings.ToUpper(resp.ColumnTypes[i])
+			continue
+		}
+		for _, row := range resp.Rows {
+			if i >= len(row) {
+				continue
+			}
+			t := cellType(row[i])
+			switch {
+			case t == "" || t == types[i]:
+			case types[i] == "":
+				types[i] = t
+			case (t == "REAL" && types[i] == "INTEGER") || (t == "INTEGER" && types[i] == "REAL"):
+				types[i] = "REAL"
+			default:
+				types[i] = "ANY"
+			}
+		}
+		if types[i] == "" {
+			types[i] = "NULL"
+		}
+	}
+	return types
+}
+
+// numericColumns reports which columns hold only numbers. NULLs are ignored,
+// but a column of nothing but NULLs is not numeric.
+func numericColumns(resp *api.QueryResponse) []bool {
+	numeric := make([]bool, len(resp.Columns))
+	for i := range numeric {
+		for _, row := range resp.Rows {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+			if _, ok := row[i].(float64); !ok {
+				numeric[i] = false
+				break
+			}
+			numeric[i] = true
+		}
+	}
+	return numeric
+}
+
+// BuildJSON converts a query result to its JSON form. Rows are arrays of
+// values, or column-keyed objects when asObjects is set.
+func BuildJSON(resp *api.QueryResponse, asObjects bool) QueryJSON {
+	result := QueryJSON{
+		Columns:       resp.Columns,
+		RowsAffected:  resp.RowsAffected,
+		LastInsertID:  resp.LastInsertID,
+		ExecutionTime: resp.ExecutionTime,
+	}
+	if result.Columns == nil {
+		result.Columns = []string{}
+	}
+	result.ColumnTypes = inferColumnTypes(resp)
+
+	if asObjects {
+		rows := make([]jsonObjectRow, 0, len(resp.Rows))
+		for _, row := range resp.Rows {
+			values := make([]interface{}, len(row))
+			for i, val := range row {
+				values[i] = jsonCellValue(val)
+			}
+			rows = append(rows, jsonObjectRow{columns: result.Columns, values: values})
+		}
+		result.Rows = rows
+	} else {
+		rows := make([][]interface{}, 0, len(resp.Rows))
+		for _, row := range resp.Rows {
+			values := make([]interface{}, len(row))
+			for i, val := range row {
+				values[i] = jsonCellValue(val)
+			}
+			rows = append(rows, values)
+		}
+		result.Rows = rows
+	}
+	return result
+}
+
+// CSVField quotes a CSV field when needed. Empty strings are always quoted so
+// they stay distinct from NULLs, which are written as nullString unquoted.
+func CSVField(val interface{}, nullString string) string {
+	if val == nil {
+		return nullString
+	}
+	str := CellText(val)
+	if str == "" || strings.ContainsAny(str, ",\"\r\n") || strings.TrimSpace(str) != str {
+		return `"` + strings.ReplaceAll(str, `"`, `""`) + `"`
+	}
+	return str
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var (
+	numberPrinterOnce sync.Once
+	numberPrinter     *message.Printer
+)
+
+// numberLocale returns the locale numbers are formatted for, from LC_ALL,
+// LC_NUMERIC or LANG as in e.g. "de_DE.UTF-8"
+func numberLocale() language.Tag {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		if value == "C" || value == "POSIX" {
+			break
+		}
+		if tag, err := language.Parse(strings.ReplaceAll(value, "_", "-")); err == nil {
+			return tag
+		}
+		break
+	}
+	return language.English
+}
+
+// FormatNumber formats a number with the locale's thousands and decimal
+// separators, keeping every significant digit
+func FormatNumber(v float64) string {
+	numberPrinterOnce.Do(func() {
+		numberPrinter = message.NewPrinter(numberLocale())
+	})
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	decimals := 0
+	plain := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(plain, '.'); i >= 0 {
+		decimals = len(plain) - i - 1
+	}
+	return numberPrinter.Sprintf(fmt.Sprintf("%%.%df", decimals), v)
+}
@@ -0,0 +1,293 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/postacksol/flux-relay-cli/internal/api"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatYAML  = "yaml"
+)
+
+// RenderOptions controls how a query result is rendered
+type RenderOptions struct {
+	// Format is FormatTable, FormatJSON, FormatYAML or FormatCSV. Empty
+	// means table.
+	Format string
+	// NullString is shown for NULL cells in table and CSV output
+	NullString string
+	// EmptyString is shown for empty strings in table output, so they
+	// aren't mistaken for NULLs
+	EmptyString string
+	// ShowTypes adds a row of inferred column types under the header in
+	// table and CSV output
+	ShowTypes bool
+	// FormatNumbers adds thousands separators to numeric columns in table
+	// output. CSV and JSON are never changed.
+	FormatNumbers bool
+	// TimeZone, when set, converts timestamp-shaped text cells (such as
+	// SQLite's datetime('now'), stored in UTC) to this zone in table output.
+	// CSV and JSON are never changed.
+	TimeZone *time.Location
+	// NoHeader leaves out the header row, the separator under it and the
+	// ShowTypes row in table and CSV output
+	NoHeader bool
+	// JSONObjects emits JSON rows as column-keyed objects instead of arrays
+	JSONObjects bool
+	// Quiet leaves out everything in table output but the header and rows:
+	// no timing, row count or rows-affected lines
+	Quiet bool
+	// MaxColumnWidth truncates table cells to this many characters. Zero
+	// means no limit.
+	MaxColumnWidth int
+	// Kind is what the statement produces. It decides whether table output
+	// shows rows or rows affected, where the result's columns alone would
+	// mislead.
+	Kind ResultKind
+}
+
+// ResultKind is what a statement produces: rows, a change, or both
+type ResultKind int
+
+const (
+	// ResultUnknown treats a result with columns as rows and one without as
+	// a change
+	ResultUnknown ResultKind = iota
+	// ResultRows is a statement that returns rows, e.g. SELECT or PRAGMA.
+	// A result without columns returned no rows.
+	ResultRows
+	// ResultChange is a statement that changes data or schema, e.g. INSERT
+	// or DROP
+	ResultChange
+	// ResultChangeReturning is a change with a RETURNING clause, which both
+	// returns rows and affects them
+	ResultChangeReturning
+)
+
+// ReturnsRows reports whether a result of kind is shown as rows
+func (k ResultKind) ReturnsRows(resp *api.QueryResponse) bool {
+	switch k {
+	case ResultRows, ResultChangeReturning:
+		return true
+	case ResultChange:
+		return false
+	default:
+		return len(resp.Columns) > 0
+	}
+}
+
+// AffectsRows reports whether a result of kind has a rows-affected count
+func (k ResultKind) AffectsRows(resp *api.QueryResponse) bool {
+	return k == ResultChangeReturning || !k.ReturnsRows(resp)
+}
+
+// RenderQueryResponse writes a query result to w. Tables list the rows of a
+// SELECT with a row count, or the rows affected by other statements and the
+// last insert ID if the result has one.
+func RenderQueryResponse(w io.Writer, resp *api.QueryResponse, opts RenderOptions) error {
+	switch opts.Format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(BuildJSON(resp, opts.JSONObjects), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode result as JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatYAML:
+		data, err := MarshalYAML(BuildJSON(resp, opts.JSONObjects))
+		if err != nil {
+			return fmt.Errorf("failed to encode result as YAML: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	case FormatCSV:
+		return renderCSV(w, resp, opts)
+	case FormatTable, "":
+		return renderTable(w, resp, opts)
+	default:
+		return fmt.Errorf("unknown output format %q", opts.Format)
+	}
+}
+
+func renderTable(w io.Writer, resp *api.QueryResponse, opts RenderOptions) error {
+	if !opts.Kind.ReturnsRows(resp) {
+		if opts.Quiet {
+			return nil
+		}
+		// INSERT/UPDATE/DELETE and other statements without a result set
+		fmt.Fprintf(w, "Query executed successfully (%dms)\n", resp.ExecutionTime)
+		return renderRowsAffected(w, resp)
+	}
+
+	if len(resp.Rows) == 0 {
+		if opts.Quiet {
+			return nil
+		}
+		fmt.Fprintf(w, "Query executed successfully (%dms)\n\n", resp.ExecutionTime)
+		_, err := fmt.Fprintln(w, "No rows returned.")
+		if opts.Kind == ResultChangeReturning && err == nil {
+			err = renderRowsAffected(w, resp)
+		}
+		return err
+	}
+	if !opts.Quiet {
+		fmt.Fprintf(w, "Query executed successfully (%dms)\n\n", resp.ExecutionTime)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	var headings [][]string
+	if !opts.NoHeader {
+		columns := make([]string, len(resp.Columns))
+		for i, col := range resp.Columns {
+			columns[i] = truncateCell(col, opts.MaxColumnWidth)
+		}
+		headings = append(headings, columns)
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+		if opts.ShowTypes {
+			types := inferColumnTypes(resp)
+			headings = append(headings, types)
+			fmt.Fprintln(tw, strings.Join(types, "\t"))
+		}
+
+		separator := make([]string, len(resp.Columns))
+		for i := range separator {
+			separator[i] = "──"
+		}
+		fmt.Fprintln(tw, strings.Join(separator, "\t"))
+	}
+
+	for _, row := range tableRows(resp, opts, headings...) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if opts.Quiet {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	_, err := fmt.Fprintf(w, "Rows returned: %d\n", len(resp.Rows))
+	if opts.Kind == ResultChangeReturning && err == nil {
+		err = renderRowsAffected(w, resp)
+	}
+	return err
+}
+
+// renderRowsAffected writes the rows affected by a change and the last
+// insert ID if the result has one
+func renderRowsAffected(w io.Writer, resp *api.QueryResponse) error {
+	_, err := fmt.Fprintf(w, "Rows affected: %d\n", resp.RowsAffected)
+	if resp.LastInsertID != nil && err == nil {
+		_, err = fmt.Fprintf(w, "Last insert ID: %d\n", *resp.LastInsertID)
+	}
+	return err
+}
+
+// tableRows formats the rows of a result for table output. Numeric columns
+// get thousands separators with opts.FormatNumbers and are padded on the left
+// to the width of their widest cell or heading line, so tabwriter, which only
+// aligns left, lines them up on the right.
+func tableRows(resp *api.QueryResponse, opts RenderOptions, headings ...[]string) [][]string {
+	rows := make([][]string, len(resp.Rows))
+	for r, row := range resp.Rows {
+		rows[r] = make([]string, len(row))
+		for i, val := range row {
+			rows[r][i] = truncateCell(displayCell(val, opts), opts.MaxColumnWidth)
+		}
+	}
+
+	for i, numeric := range numericColumns(resp) {
+		if !numeric {
+			continue
+		}
+		if opts.FormatNumbers {
+			for r, row := range resp.Rows {
+				if v, ok := row[i].(float64); ok {
+					rows[r][i] = FormatNumber(v)
+				}
+			}
+		}
+		width := 0
+		for _, heading := range headings {
+			if i < len(heading) {
+				width = max(width, utf8.RuneCountInString(heading[i]))
+			}
+		}
+		for _, row := range rows {
+			if i < len(row) {
+				width = max(width, utf8.RuneCountInString(row[i]))
+			}
+		}
+		for _, row := range rows {
+			if i < len(row) {
+				row[i] = strings.Repeat(" ", width-utf8.RuneCountInString(row[i])) + row[i]
+			}
+		}
+	}
+	return rows
+}
+
+// truncateCell shortens text to width characters, ending in "…". Newlines
+// are flattened so a cell never breaks the table's rows.
+func truncateCell(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	text = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// renderCSV writes the columns and rows of a query result as CSV. With
+// opts.ShowTypes, a second header row holds the inferred column types; with
+// opts.NoHeader there are no header rows.
+func renderCSV(w io.Writer, resp *api.QueryResponse, opts RenderOptions) error {
+	if len(resp.Columns) == 0 {
+		return nil
+	}
+
+	fields := make([]string, len(resp.Columns))
+	if !opts.NoHeader {
+		for i, col := range resp.Columns {
+			fields[i] = CSVField(col, opts.NullString)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, ",")); err != nil {
+			return err
+		}
+		if opts.ShowTypes {
+			if _, err := fmt.Fprintln(w, strings.Join(inferColumnTypes(resp), ",")); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, row := range resp.Rows {
+		fields = fields[:0]
+		for _, val := range row {
+			fields = append(fields, CSVField(val, opts.NullString))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
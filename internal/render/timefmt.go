@@ -0,0 +1,46 @@
+package render
+
+import (
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the timestamp shapes converted by RenderOptions.TimeZone:
+// SQLite's datetime('now') text, with or without fractional seconds, and
+// ISO-8601 with a "T" separator. Values without a zone are taken to be UTC,
+// as SQLite writes them.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+}
+
+// displayTimestampLayout is how converted timestamps are shown. Fractional
+// seconds are kept when the value has them.
+const displayTimestampLayout = "2006-01-02 15:04:05.999999999 MST"
+
+// convertTimestamp returns text shown in loc if it is a timestamp in one of
+// timestampLayouts. Anything else is returned unchanged.
+func convertTimestamp(text string, loc *time.Location) string {
+	// Cheap shape check before trying the layouts: "YYYY-MM-DD" then a
+	// date/time separator
+	if len(text) < 19 || text[4] != '-' || text[7] != '-' || (text[10] != ' ' && text[10] != 'T') {
+		return text
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(text), time.UTC); err == nil {
+			return t.In(loc).Format(displayTimestampLayout)
+		}
+	}
+	return text
+}
+
+// LoadTimeZone resolves a --tz value: "local" for the system's zone, "UTC",
+// or an IANA name such as "Europe/Berlin"
+func LoadTimeZone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
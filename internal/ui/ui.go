@@ -0,0 +1,77 @@
+// Package ui styles the status lines commands print. Each kind of line has
+// its symbol, and its text is colored when stdout is a terminal.
+package ui
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	red    = "\x1b[31m"
+	cyan   = "\x1b[36m"
+	reset  = "\x1b[0m"
+)
+
+var colorEnabled = detectColor()
+
+// detectColor reports whether stdout is a terminal that shows colors. Setting
+// NO_COLOR (https://no-color.org) or TERM=dumb turns colors off.
+func detectColor() bool {
+	if _, set := os.LookupEnv("NO_COLOR"); set || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetColor turns colors on or off, overriding the detected default
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// ColorEnabled reports whether output is colored
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
+// style formats a line as symbol followed by the text in color. The symbol
+// itself is never colored; ⚠️ and ℹ️ take two spaces as most terminals draw
+// them one column wide.
+func style(color, symbol, format string, args []interface{}) string {
+	text := fmt.Sprintf(format, args...)
+	if colorEnabled {
+		text = color + text + reset
+	}
+	return symbol + text
+}
+
+// Success formats a line reporting something done, e.g. "✅ Saved"
+func Success(format string, args ...interface{}) string {
+	return style(green, "✅ ", format, args)
+}
+
+// Warn formats a warning line
+func Warn(format string, args ...interface{}) string {
+	return style(yellow, "⚠️  ", format, args)
+}
+
+// Error formats a line reporting a failure that is shown rather than returned
+func Error(format string, args ...interface{}) string {
+	return style(red, "❌ ", format, args)
+}
+
+// Info formats an informational line
+func Info(format string, args ...interface{}) string {
+	return style(cyan, "ℹ️  ", format, args)
+}
+
+// Hint formats a line suggesting what to do next
+func Hint(format string, args ...interface{}) string {
+	return style(cyan, "💡 ", format, args)
+}